@@ -1,111 +1,433 @@
-package main
-
-import (
-	"context"
-	"fmt"
-	"log"
-	"os"
-	"time"
-	"tritontube/internal/proto"
-
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-)
-
-func main() {
-	if len(os.Args) < 3 { // Minimum 3 args: program, command, server_address
-		printUsageAndExit()
-	}
-
-	cmd := os.Args[1]
-	serverAddr := os.Args[2]
-
-	conn, err := grpc.NewClient(serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		log.Fatalf("Failed to connect to server: %v", err)
-	}
-	defer conn.Close()
-
-	client := proto.NewVideoContentAdminServiceClient(conn)
-
-	switch cmd {
-	case "add":
-		if len(os.Args) != 4 {
-			fmt.Println("Usage: add <server_address> <node_address>")
-			os.Exit(1)
-		}
-		addNode(client, os.Args[3])
-	case "remove":
-		if len(os.Args) != 4 {
-			fmt.Println("Usage: remove <server_address> <node_address>")
-			os.Exit(1)
-		}
-		removeNode(client, os.Args[3])
-	case "list":
-		if len(os.Args) != 3 {
-			fmt.Println("Usage: list <server_address>")
-			os.Exit(1)
-		}
-		listNodes(client)
-	default:
-		fmt.Printf("Unknown command: %s\n", cmd)
-		printUsageAndExit()
-	}
-}
-
-func printUsageAndExit() {
-	fmt.Println("Usage:")
-	fmt.Println("  add <server_address> <node_address>     - Add a node to the cluster")
-	fmt.Println("  remove <server_address> <node_address>  - Remove a node from the cluster")
-	fmt.Println("  list <server_address>                   - List all nodes in the cluster")
-	os.Exit(1)
-}
-
-func addNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-
-	response, err := client.AddNode(ctx, &proto.AddNodeRequest{
-		NodeAddress: nodeAddr,
-	})
-	if err != nil {
-		log.Fatalf("AddNode RPC failed: %v", err)
-	}
-
-	fmt.Printf("Successfully added node: %s\n", nodeAddr)
-	fmt.Printf("Number of files migrated: %d\n", response.MigratedFileCount)
-}
-
-func removeNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-
-	response, err := client.RemoveNode(ctx, &proto.RemoveNodeRequest{
-		NodeAddress: nodeAddr,
-	})
-	if err != nil {
-		log.Fatalf("RemoveNode RPC failed: %v", err)
-	}
-
-	fmt.Printf("Successfully removed node: %s\n", nodeAddr)
-	fmt.Printf("Number of files migrated: %d\n", response.MigratedFileCount)
-}
-
-func listNodes(client proto.VideoContentAdminServiceClient) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-
-	response, err := client.ListNodes(ctx, &proto.ListNodesRequest{})
-	if err != nil {
-		log.Fatalf("ListNodes RPC failed: %v", err)
-	}
-
-	fmt.Println("Storage cluster nodes:")
-	if len(response.Nodes) == 0 {
-		fmt.Println("  No nodes in cluster")
-	} else {
-		for _, node := range response.Nodes {
-			fmt.Printf("  - %s\n", node)
-		}
-	}
-}
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"tritontube/internal/proto"
+	"tritontube/internal/rpcauth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	timeout = flag.Duration("timeout", time.Hour, "How long to wait for the command to finish")
+	dryRun  = flag.Bool("dry-run", false, "Report which files would move without changing anything")
+	asJSON  = flag.Bool("json", false, "For status, print JSON instead of a table")
+
+	gracePeriod = flag.Duration("grace-period", 0, "For gc, keep orphaned files modified more recently than this (0 for the web server's default)")
+
+	concurrency       = flag.Int("concurrency", 0, "Number of files copied at once when moving files (0 for the server default)")
+	maxBytesPerSecond = flag.Int64("max-bytes-per-second", 0, "Cap on the copy rate when moving files (0 for unlimited)")
+
+	rpcSecurity = rpcauth.Flags(flag.CommandLine)
+)
+
+func main() {
+	flag.Usage = printUsage
+	flag.Parse()
+	args := flag.Args()
+	if len(args) < 2 { // Minimum 2 args: command, server_address
+		printUsageAndExit()
+	}
+
+	cmd := args[0]
+	serverAddr := args[1]
+
+	dialOpts, err := rpcSecurity.DialOptions()
+	if err != nil {
+		log.Fatalf("Failed to set up security: %v", err)
+	}
+	conn, err := grpc.NewClient(serverAddr, dialOpts...)
+	if err != nil {
+		log.Fatalf("Failed to connect to server: %v", err)
+	}
+	defer conn.Close()
+
+	client := proto.NewVideoContentAdminServiceClient(conn)
+
+	switch cmd {
+	case "add":
+		if len(args) != 3 {
+			fmt.Println("Usage: add <server_address> <node_address>")
+			os.Exit(1)
+		}
+		migrateNode(client, proto.MigrateNodeRequest_ADD, args[2])
+	case "remove":
+		if len(args) != 3 {
+			fmt.Println("Usage: remove <server_address> <node_address>")
+			os.Exit(1)
+		}
+		migrateNode(client, proto.MigrateNodeRequest_REMOVE, args[2])
+	case "drain":
+		if len(args) != 3 {
+			fmt.Println("Usage: drain <server_address> <node_address>")
+			os.Exit(1)
+		}
+		migrateNode(client, proto.MigrateNodeRequest_DRAIN, args[2])
+	case "undrain":
+		if len(args) != 3 {
+			fmt.Println("Usage: undrain <server_address> <node_address>")
+			os.Exit(1)
+		}
+		migrateNode(client, proto.MigrateNodeRequest_UNDRAIN, args[2])
+	case "list":
+		if len(args) != 2 {
+			fmt.Println("Usage: list <server_address>")
+			os.Exit(1)
+		}
+		listNodes(client)
+	case "status":
+		if len(args) != 2 {
+			fmt.Println("Usage: status <server_address>")
+			os.Exit(1)
+		}
+		clusterStatus(client)
+	case "rebalance":
+		if len(args) != 2 {
+			fmt.Println("Usage: rebalance <server_address>")
+			os.Exit(1)
+		}
+		rebalance(client)
+	case "retranscode":
+		if len(args) != 3 {
+			fmt.Println("Usage: retranscode <server_address> <video_id>")
+			os.Exit(1)
+		}
+		retranscode(client, args[2])
+	case "retranscode-all":
+		if len(args) != 2 {
+			fmt.Println("Usage: retranscode-all <server_address>")
+			os.Exit(1)
+		}
+		retranscodeAll(client)
+	case "gc":
+		if len(args) != 2 {
+			fmt.Println("Usage: gc <server_address>")
+			os.Exit(1)
+		}
+		collectGarbage(client)
+	default:
+		fmt.Printf("Unknown command: %s\n", cmd)
+		printUsageAndExit()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: admin [OPTIONS] COMMAND <server_address> [ARGS]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  add <server_address> <node_address>     - Add a node to the cluster")
+	fmt.Println("  remove <server_address> <node_address>  - Remove a node from the cluster")
+	fmt.Println("  drain <server_address> <node_address>   - Stop writes to a node and move its files off it")
+	fmt.Println("  undrain <server_address> <node_address> - Bring a drained node back into service")
+	fmt.Println("  list <server_address>                   - List all nodes in the cluster")
+	fmt.Println("  status <server_address>                 - Show files, bytes, keyspace share and health per node")
+	fmt.Println("  rebalance <server_address>              - Move files that are on the wrong node")
+	fmt.Println("  retranscode <server_address> <video_id>")
+	fmt.Println("                                          - Regenerate a video's DASH files from its kept original upload")
+	fmt.Println("  retranscode-all <server_address>        - Regenerate every video with the web server's encoding settings")
+	fmt.Println("  gc <server_address>                     - Delete stored files whose video no longer exists")
+	fmt.Println()
+	fmt.Println("retranscode and retranscode-all need the web server to run with -originals-dir; with fs, give it -admin-addr to serve admin commands.")
+	fmt.Println("gc also talks to the web server; use -dry-run to only list orphaned files.")
+	fmt.Println("An interrupted add, remove, drain or undrain resumes when the same command is run again.")
+	fmt.Println()
+	fmt.Println("Options:")
+	flag.PrintDefaults()
+}
+
+func printUsageAndExit() {
+	printUsage()
+	os.Exit(1)
+}
+
+func migrateNode(client proto.VideoContentAdminServiceClient, action proto.MigrateNodeRequest_Action, nodeAddr string) {
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	stream, err := client.MigrateNode(ctx, &proto.MigrateNodeRequest{
+		NodeAddress: nodeAddr,
+		Action:      action,
+		DryRun:      *dryRun,
+		Options:     migrationOptions(),
+	})
+	if err != nil {
+		log.Fatalf("MigrateNode RPC failed: %v", err)
+	}
+	summary := followMigration("MigrateNode", stream)
+	printMigrationSummary(action, nodeAddr, summary)
+}
+
+func rebalance(client proto.VideoContentAdminServiceClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	stream, err := client.Rebalance(ctx, &proto.RebalanceRequest{
+		DryRun:  *dryRun,
+		Options: migrationOptions(),
+	})
+	if err != nil {
+		log.Fatalf("Rebalance RPC failed: %v", err)
+	}
+	summary := followMigration("Rebalance", stream)
+	if summary.DryRun {
+		fmt.Printf("Dry run: no changes made to the cluster\n")
+		fmt.Printf("Number of files that would be migrated: %d (%s)\n", summary.TotalFileCount, formatBytes(summary.TotalBytes))
+		return
+	}
+	fmt.Println("Successfully rebalanced cluster")
+	fmt.Printf("Number of files migrated: %d (%s)\n", summary.MigratedFileCount, formatBytes(summary.MigratedBytes))
+}
+
+func retranscode(client proto.VideoContentAdminServiceClient, videoId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	started := time.Now()
+	video, err := client.RetranscodeVideo(ctx, &proto.RetranscodeVideoRequest{Id: videoId})
+	if err != nil {
+		log.Fatalf("RetranscodeVideo RPC failed: %v", err)
+	}
+	fmt.Printf("Re-transcoded %s in %s\n", video.Id, time.Since(started).Round(time.Second))
+}
+
+func retranscodeAll(client proto.VideoContentAdminServiceClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	stream, err := client.RetranscodeAll(ctx, &proto.RetranscodeAllRequest{DryRun: *dryRun})
+	if err != nil {
+		log.Fatalf("RetranscodeAll RPC failed: %v", err)
+	}
+	counts := make(map[proto.RetranscodeProgress_State]int)
+	for {
+		progress, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			log.Fatalf("RetranscodeAll RPC failed: %v", err)
+		}
+
+		prefix := fmt.Sprintf("  [%d/%d] %s", progress.Done, progress.Total, progress.VideoId)
+		switch progress.State {
+		case proto.RetranscodeProgress_RUNNING:
+			fmt.Printf("%s: %.0f%%\n", prefix, progress.Progress)
+			continue
+		case proto.RetranscodeProgress_SUCCEEDED:
+			fmt.Printf("%s: done\n", prefix)
+		case proto.RetranscodeProgress_PENDING:
+			fmt.Printf("%s: would re-transcode\n", prefix)
+		default:
+			fmt.Printf("%s: %s: %s\n", prefix, strings.ToLower(progress.State.String()), progress.Error)
+		}
+		counts[progress.State]++
+	}
+
+	if *dryRun {
+		fmt.Printf("Dry run: no videos changed\n")
+		fmt.Printf("Number of videos that would be re-transcoded: %d\n", counts[proto.RetranscodeProgress_PENDING])
+		return
+	}
+	fmt.Printf("Re-transcoded %d videos, skipped %d without an original, %d failed\n",
+		counts[proto.RetranscodeProgress_SUCCEEDED], counts[proto.RetranscodeProgress_SKIPPED], counts[proto.RetranscodeProgress_FAILED])
+	if counts[proto.RetranscodeProgress_FAILED] > 0 {
+		os.Exit(1)
+	}
+}
+
+func collectGarbage(client proto.VideoContentAdminServiceClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	stream, err := client.CollectGarbage(ctx, &proto.CollectGarbageRequest{
+		DryRun:             *dryRun,
+		GracePeriodSeconds: int64(gracePeriod.Seconds()),
+	})
+	if err != nil {
+		log.Fatalf("CollectGarbage RPC failed: %v", err)
+	}
+	var orphans, deleted, failed int
+	var orphanBytes, deletedBytes int64
+	for {
+		file, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			log.Fatalf("CollectGarbage RPC failed: %v", err)
+		}
+
+		orphans++
+		orphanBytes += file.Size
+		name := file.VideoId + "/" + file.Filename
+		if file.Original {
+			name += " (original)"
+		}
+		age := time.Since(file.ModifiedAt.AsTime()).Round(time.Second)
+		switch {
+		case file.Deleted:
+			deleted++
+			deletedBytes += file.Size
+			fmt.Printf("  deleted %s: %s, modified %s ago\n", name, formatBytes(file.Size), age)
+		case file.Error != "":
+			failed++
+			fmt.Printf("  failed to delete %s: %s\n", name, file.Error)
+		case *dryRun:
+			fmt.Printf("  orphan %s: %s, modified %s ago\n", name, formatBytes(file.Size), age)
+		default:
+			fmt.Printf("  kept %s: %s, modified %s ago, within the grace period\n", name, formatBytes(file.Size), age)
+		}
+	}
+
+	if *dryRun {
+		fmt.Printf("Dry run: no files deleted\n")
+		fmt.Printf("Number of orphaned files: %d (%s)\n", orphans, formatBytes(orphanBytes))
+		return
+	}
+	fmt.Printf("Deleted %d of %d orphaned files (%s), %d failed\n", deleted, orphans, formatBytes(deletedBytes), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// followMigration prints progress from stream and returns the final summary.
+func followMigration(rpc string, stream grpc.ServerStreamingClient[proto.MigrationProgress]) *proto.MigrationProgress {
+	started := false
+	for {
+		progress, err := stream.Recv()
+		if err == io.EOF {
+			log.Fatalf("%s RPC ended before the migration finished", rpc)
+		} else if err != nil && started {
+			log.Fatalf("%s RPC failed: %v (re-run the command to resume)", rpc, err)
+		} else if err != nil {
+			log.Fatalf("%s RPC failed: %v", rpc, err)
+		}
+		started = true
+
+		if progress.Done {
+			return progress
+		}
+		if progress.DryRun {
+			fmt.Printf("  would move %s/%s: %s -> %s\n",
+				progress.CurrentVideoId, progress.CurrentFilename, progress.SourceNode, progress.DestinationNode)
+			continue
+		}
+		fmt.Printf("  [%d/%d files, %s/%s, ETA %s] %s/%s: %s -> %s\n",
+			progress.MigratedFileCount, progress.TotalFileCount,
+			formatBytes(progress.MigratedBytes), formatBytes(progress.TotalBytes),
+			time.Duration(progress.EtaSeconds)*time.Second,
+			progress.CurrentVideoId, progress.CurrentFilename, progress.SourceNode, progress.DestinationNode)
+	}
+}
+
+func migrationOptions() *proto.MigrationOptions {
+	return &proto.MigrationOptions{
+		Concurrency:       int32(*concurrency),
+		MaxBytesPerSecond: *maxBytesPerSecond,
+	}
+}
+
+func printMigrationSummary(action proto.MigrateNodeRequest_Action, nodeAddr string, progress *proto.MigrationProgress) {
+	verb := map[proto.MigrateNodeRequest_Action]string{
+		proto.MigrateNodeRequest_ADD:     "added",
+		proto.MigrateNodeRequest_REMOVE:  "removed",
+		proto.MigrateNodeRequest_DRAIN:   "drained",
+		proto.MigrateNodeRequest_UNDRAIN: "undrained",
+	}[action]
+	if progress.DryRun {
+		fmt.Printf("Dry run: no changes made to the cluster\n")
+		fmt.Printf("Number of files that would be migrated: %d (%s)\n", progress.TotalFileCount, formatBytes(progress.TotalBytes))
+		return
+	}
+	fmt.Printf("Successfully %s node: %s\n", verb, nodeAddr)
+	fmt.Printf("Number of files migrated: %d (%s)\n", progress.MigratedFileCount, formatBytes(progress.MigratedBytes))
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func listNodes(client proto.VideoContentAdminServiceClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	response, err := client.ListNodes(ctx, &proto.ListNodesRequest{})
+	if err != nil {
+		log.Fatalf("ListNodes RPC failed: %v", err)
+	}
+
+	fmt.Println("Storage cluster nodes:")
+	if len(response.Nodes) == 0 {
+		fmt.Println("  No nodes in cluster")
+	} else {
+		for _, node := range response.Nodes {
+			if slices.Contains(response.DrainingNodes, node) {
+				fmt.Printf("  - %s (draining)\n", node)
+			} else {
+				fmt.Printf("  - %s\n", node)
+			}
+		}
+	}
+}
+
+func clusterStatus(client proto.VideoContentAdminServiceClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	response, err := client.ClusterStatus(ctx, &proto.ClusterStatusRequest{})
+	if err != nil {
+		log.Fatalf("ClusterStatus RPC failed: %v", err)
+	}
+
+	if *asJSON {
+		out, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(response)
+		if err != nil {
+			log.Fatalf("Failed to encode status: %v", err)
+		}
+		fmt.Println(string(out))
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tSTATE\tFILES\tBYTES\tKEYSPACE\tMISPLACED")
+	for _, node := range response.Nodes {
+		state := "up"
+		switch {
+		case !node.Healthy:
+			state = "down"
+		case node.Removing:
+			state = "removing"
+		case node.Draining:
+			state = "draining"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%.1f%%\t%d\n",
+			node.Address, state, node.FileCount, formatBytes(node.TotalBytes), node.KeyspaceShare*100, node.MisplacedFileCount)
+	}
+	w.Flush()
+
+	for _, node := range response.Nodes {
+		if !node.Healthy {
+			fmt.Printf("%s: %s\n", node.Address, node.Error)
+		}
+	}
+	if response.UnfinishedMigration != "" {
+		fmt.Printf("Unfinished migration: %s (re-run it or rebalance to complete it)\n", response.UnfinishedMigration)
+	}
+}
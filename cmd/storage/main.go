@@ -1,31 +1,61 @@
-package main
-
-import (
-	"flag"
-	"fmt"
-)
-
-func main() {
-	host := flag.String("host", "localhost", "Host address for the server")
-	port := flag.Int("port", 8090, "Port number for the server")
-	flag.Parse()
-
-	// Validate arguments
-	if *port <= 0 {
-		panic("Error: Port number must be positive")
-	}
-
-	if flag.NArg() < 1 {
-		fmt.Println("Usage: storage [OPTIONS] <baseDir>")
-		fmt.Println("Error: Base directory argument is required")
-		return
-	}
-	baseDir := flag.Arg(0)
-
-	fmt.Println("Starting storage server...")
-	fmt.Printf("Host: %s\n", *host)
-	fmt.Printf("Port: %d\n", *port)
-	fmt.Printf("Base Directory: %s\n", baseDir)
-
-	panic("Lab 8: not implemented")
-}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"tritontube/internal/proto"
+	"tritontube/internal/rpcauth"
+	"tritontube/internal/storage"
+
+	"google.golang.org/grpc"
+)
+
+func main() {
+	host := flag.String("host", "localhost", "Host address for the server")
+	port := flag.Int("port", 8090, "Port number for the server")
+	rpcSecurity := rpcauth.Flags(flag.CommandLine)
+	flag.Parse()
+
+	// Validate arguments
+	if *port <= 0 {
+		panic("Error: Port number must be positive")
+	}
+
+	if flag.NArg() < 1 {
+		fmt.Println("Usage: storage [OPTIONS] <baseDir>")
+		fmt.Println("Error: Base directory argument is required")
+		return
+	}
+	baseDir := flag.Arg(0)
+
+	fmt.Println("Starting storage server...")
+	fmt.Printf("Host: %s\n", *host)
+	fmt.Printf("Port: %d\n", *port)
+	fmt.Printf("Base Directory: %s\n", baseDir)
+
+	listenAddr := fmt.Sprintf("%s:%d", *host, *port)
+	lis, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		fmt.Println("Error starting listener:", err)
+		return
+	}
+	defer lis.Close()
+
+	serverOpts, err := rpcSecurity.ServerOptions()
+	if err != nil {
+		fmt.Println("Error setting up security:", err)
+		return
+	}
+	serverOpts = append(serverOpts,
+		grpc.MaxRecvMsgSize(storage.MaxMessageSize),
+		grpc.MaxSendMsgSize(storage.MaxMessageSize),
+	)
+	grpcServer := grpc.NewServer(serverOpts...)
+	proto.RegisterVideoContentStorageServiceServer(grpcServer, storage.NewStorageServer(baseDir))
+	err = grpcServer.Serve(lis)
+	if err != nil {
+		fmt.Println("Error serving:", err)
+		return
+	}
+}
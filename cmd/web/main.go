@@ -4,9 +4,21 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"tritontube/internal/proto"
+	"tritontube/internal/rpcauth"
+	"tritontube/internal/storage"
 	"tritontube/internal/web"
+
+	"google.golang.org/grpc"
 )
 
+// urlSigningKeyEnv is read when -url-signing-key is not given, keeping the secret off the command line.
+const urlSigningKeyEnv = "TRITONTUBE_URL_SIGNING_KEY"
+
 // printUsage prints the usage information for the application
 func printUsage() {
 	fmt.Println("Usage: ./program [OPTIONS] METADATA_TYPE METADATA_OPTIONS CONTENT_TYPE CONTENT_OPTIONS")
@@ -21,12 +33,57 @@ func printUsage() {
 	flag.PrintDefaults()
 	fmt.Println()
 	fmt.Println("Example: ./program sqlite db.db fs /path/to/videos")
+	fmt.Println("Example: ./program sqlite db.db nw localhost:8081,localhost:8090,localhost:8091")
+	fmt.Println("         (for nw, the first address is where the admin service listens)")
+	fmt.Println("Example: ./program -admin-addr localhost:8081 sqlite db.db fs /path/to/videos")
+	fmt.Println("         (admin commands like retranscode and gc also work with fs)")
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
 }
 
 func main() {
 	// Define flags
 	port := flag.Int("port", 8080, "Port number for the web server")
 	host := flag.String("host", "localhost", "Host address for the web server")
+	membershipEtcd := flag.String("membership-etcd", "", "Comma-separated etcd endpoints for storing cluster membership (nw only)")
+	membershipFile := flag.String("membership-file", "membership.json", "File for storing cluster membership when etcd is not used (nw only)")
+	urlSigningKey := flag.String("url-signing-key", "", "Secret for signing content URLs; share it between web servers behind one CDN (default $"+urlSigningKeyEnv+", else random per start)")
+	grpcPort := flag.Int("grpc-port", 0, "Port for the gRPC VideoService, an internal API for trusted services, on the same host (0 to disable; needs -auth-token or -tls-ca)")
+	adminAddr := flag.String("admin-addr", "", "Address for the admin service with fs, e.g. localhost:8081 (empty to disable); with nw it is the first address of CONTENT_OPTIONS")
+	contentURLTTL := flag.Duration("content-url-ttl", 6*time.Hour, "How long signed content URLs stay valid")
+	allowedContainers := flag.String("allowed-containers", strings.Join(web.DefaultAllowedContainers, ","), "Comma-separated ffprobe container names accepted for upload")
+	allowedVideoCodecs := flag.String("allowed-video-codecs", strings.Join(web.DefaultAllowedVideoCodecs, ","), "Comma-separated ffprobe video codec names accepted for upload")
+	allowedAudioCodecs := flag.String("allowed-audio-codecs", strings.Join(web.DefaultAllowedAudioCodecs, ","), "Comma-separated ffprobe audio codec names accepted for upload")
+	maxDuration := flag.Duration("max-duration", web.DefaultMaxDuration, "Longest video accepted for upload")
+	maxWidth := flag.Int("max-width", web.DefaultMaxWidth, "Largest video width accepted for upload (height for portrait videos)")
+	maxHeight := flag.Int("max-height", web.DefaultMaxHeight, "Largest video height accepted for upload (width for portrait videos)")
+	maxFrameRate := flag.Float64("max-frame-rate", web.DefaultMaxFrameRate, "Highest frame rate accepted for upload")
+	maxUploadBytes := flag.Int64("max-upload-bytes", web.DefaultMaxUploadBytes, "Largest file accepted for upload, in bytes")
+	transcodeTimeout := flag.Duration("transcode-timeout", web.DefaultTranscodeTimeout, "How long ffmpeg may work on one upload before it is killed")
+	transcodeThreads := flag.Int("transcode-threads", 0, "Threads per ffmpeg process (0 lets ffmpeg decide)")
+	transcodeNice := flag.Int("transcode-nice", 10, "Niceness added to ffmpeg processes so transcodes don't starve the web server (0 to disable)")
+	originalsDir := flag.String("originals-dir", "", "Keep uploaded files in this directory, e.g. on cheaper storage, so videos can be re-transcoded (empty to discard them)")
+	compressOriginals := flag.Bool("compress-originals", false, "Gzip the files kept in -originals-dir")
+	videoCodec := flag.String("video-codec", "", "ffmpeg video encoder for DASH renditions, e.g. libx264 (empty for ffmpeg's default)")
+	audioCodec := flag.String("audio-codec", "", "ffmpeg audio encoder for DASH tracks, e.g. aac (empty for ffmpeg's default)")
+	ladder := flag.String("ladder", "", "Comma-separated rendition heights, e.g. 1080,720,480; heights above the source are left out (empty for the source resolution only)")
+	segmentDuration := flag.Duration("segment-duration", 0, "Target DASH segment duration (0 for ffmpeg's default)")
+	versionGracePeriod := flag.Duration("version-grace-period", 0, "How long the files of a replaced transcode are kept for viewers still playing it (0 for -content-url-ttl)")
+	gcInterval := flag.Duration("gc-interval", 0, "How often to delete orphaned files, like the admin gc command (0 to disable)")
+	gcGracePeriod := flag.Duration("gc-grace-period", web.DefaultGCGracePeriod, "How old an orphaned file must be to be deleted, so uploads in progress are left alone")
+	userQuota := flag.Int64("user-quota", 0, "Most bytes each user's videos may take up in storage, kept originals included (0 for no limit; sqlite only)")
+	dedup := flag.Bool("dedup", true, "Store identical files once, indexed in the metadata service (sqlite only)")
+	// used for the admin service, the gRPC VideoService and connecting to storage nodes
+	rpcSecurity := rpcauth.Flags(flag.CommandLine)
 
 	// Set custom usage message
 	flag.Usage = printUsage
@@ -54,6 +111,25 @@ func main() {
 		return
 	}
 
+	// VideoService callers see every video and name the uploader, so only
+	// authenticated services may call it
+	if *grpcPort > 0 && !rpcSecurity.AuthenticatesClients() {
+		fmt.Println("Error: -grpc-port needs -auth-token (or $" + rpcauth.TokenEnv + ") or -tls-ca so only trusted services can call it")
+		printUsage()
+		return
+	}
+
+	var ladderHeights []int
+	for _, height := range splitList(*ladder) {
+		h, err := strconv.Atoi(height)
+		if err != nil || h <= 0 {
+			fmt.Println("Error: Invalid ladder height:", height)
+			printUsage()
+			return
+		}
+		ladderHeights = append(ladderHeights, h)
+	}
+
 	// Construct metadata service
 	var metadataService web.VideoMetadataService
 	fmt.Println("Creating metadata service of type", metadataServiceType, "with options", metadataServiceOptions)
@@ -69,14 +145,132 @@ func main() {
 
 	// Construct content service
 	var contentService web.VideoContentService
+	// answers the cluster RPCs of the admin service, with nw
+	var cluster proto.VideoContentAdminServiceServer
 	fmt.Println("Creating content service of type", contentServiceType, "with options", contentServiceOptions)
 	// TODO: Implement content service creation logic
 	if contentServiceType == "fs" {
 		contentService = web.NewFSVideoContentService(contentServiceOptions)
+	} else if contentServiceType == "nw" {
+		if *adminAddr != "" {
+			fmt.Println("Error: -admin-addr is only for fs; with nw the first address is the admin address")
+			printUsage()
+			return
+		}
+		addrs := strings.Split(contentServiceOptions, ",")
+		nodes := addrs[1:]
+		*adminAddr = addrs[0]
+
+		var store web.MembershipStore
+		if *membershipEtcd != "" {
+			etcdStore, err := web.NewEtcdMembershipStore(strings.Split(*membershipEtcd, ","))
+			if err != nil {
+				fmt.Println("Error connecting to etcd:", err)
+				return
+			}
+			defer etcdStore.Close()
+			store = etcdStore
+		} else {
+			store = web.NewFileMembershipStore(*membershipFile)
+		}
+
+		dialOpts, err := rpcSecurity.DialOptions()
+		if err != nil {
+			fmt.Println("Error setting up storage node security:", err)
+			return
+		}
+		svc, err := web.NewNetworkVideoContentService(nodes, store, dialOpts...)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer svc.Close()
+		contentService = svc
+		cluster = svc
+	}
+
+	// identical segments, e.g. of the same file uploaded twice, are stored once
+	if index, ok := metadataService.(web.BlobIndex); ok && *dedup {
+		contentService = web.NewDedupVideoContentService(contentService, index)
 	}
 
 	// Start the server
-	server := web.NewServer(metadataService, contentService)
+	if *urlSigningKey == "" {
+		*urlSigningKey = os.Getenv(urlSigningKeyEnv)
+	}
+	if *urlSigningKey == "" {
+		fmt.Println("No URL signing key set; content URLs will stop working when the server restarts")
+	}
+	var originals web.VideoContentService
+	if *originalsDir != "" {
+		originals = web.NewFSVideoContentService(*originalsDir)
+	}
+	server := web.NewServer(metadataService, contentService, web.Config{
+		URLSigningKey: []byte(*urlSigningKey),
+		ContentURLTTL: *contentURLTTL,
+
+		AllowedContainers:  splitList(*allowedContainers),
+		AllowedVideoCodecs: splitList(*allowedVideoCodecs),
+		AllowedAudioCodecs: splitList(*allowedAudioCodecs),
+		MaxDuration:        *maxDuration,
+		MaxWidth:           *maxWidth,
+		MaxHeight:          *maxHeight,
+		MaxFrameRate:       *maxFrameRate,
+		MaxUploadBytes:     *maxUploadBytes,
+		TranscodeTimeout:   *transcodeTimeout,
+		TranscodeThreads:   *transcodeThreads,
+		TranscodeNice:      *transcodeNice,
+		Originals:          originals,
+		CompressOriginals:  *compressOriginals,
+		Encoding: web.EncodingProfile{
+			VideoCodec:      *videoCodec,
+			AudioCodec:      *audioCodec,
+			Ladder:          ladderHeights,
+			SegmentDuration: *segmentDuration,
+		},
+		VersionGracePeriod: *versionGracePeriod,
+		GCInterval:         *gcInterval,
+		GCGracePeriod:      *gcGracePeriod,
+		UserQuotaBytes:     *userQuota,
+	})
+
+	if *adminAddr != "" {
+		serverOpts, err := rpcSecurity.ServerOptions()
+		if err != nil {
+			fmt.Println("Error setting up admin service security:", err)
+			return
+		}
+		adminLis, err := net.Listen("tcp", *adminAddr)
+		if err != nil {
+			fmt.Println("Error starting admin listener:", err)
+			return
+		}
+		defer adminLis.Close()
+		grpcServer := grpc.NewServer(serverOpts...)
+		proto.RegisterVideoContentAdminServiceServer(grpcServer, web.NewAdminService(server, cluster))
+		fmt.Println("Starting admin service on", *adminAddr)
+		go grpcServer.Serve(adminLis)
+	}
+
+	if *grpcPort > 0 {
+		serverOpts, err := rpcSecurity.ServerOptions()
+		if err != nil {
+			fmt.Println("Error setting up video service security:", err)
+			return
+		}
+		serverOpts = append(serverOpts, grpc.MaxRecvMsgSize(storage.MaxMessageSize))
+		grpcAddr := fmt.Sprintf("%s:%d", *host, *grpcPort)
+		grpcLis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			fmt.Println("Error starting video service listener:", err)
+			return
+		}
+		defer grpcLis.Close()
+		grpcServer := grpc.NewServer(serverOpts...)
+		proto.RegisterVideoServiceServer(grpcServer, web.NewVideoService(server))
+		fmt.Println("Starting video service on", grpcAddr)
+		go grpcServer.Serve(grpcLis)
+	}
 	listenAddr := fmt.Sprintf("%s:%d", *host, *port)
 	lis, err := net.Listen("tcp", listenAddr)
 	if err != nil {
@@ -1,198 +1,553 @@
-// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
-// versions:
-// - protoc-gen-go-grpc v1.5.1
-// - protoc             v5.29.3
-// source: proto/admin.proto
-
-package proto
-
-import (
-	context "context"
-	grpc "google.golang.org/grpc"
-	codes "google.golang.org/grpc/codes"
-	status "google.golang.org/grpc/status"
-)
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-// Requires gRPC-Go v1.64.0 or later.
-const _ = grpc.SupportPackageIsVersion9
-
-const (
-	VideoContentAdminService_AddNode_FullMethodName    = "/tritontube.VideoContentAdminService/AddNode"
-	VideoContentAdminService_RemoveNode_FullMethodName = "/tritontube.VideoContentAdminService/RemoveNode"
-	VideoContentAdminService_ListNodes_FullMethodName  = "/tritontube.VideoContentAdminService/ListNodes"
-)
-
-// VideoContentAdminServiceClient is the client API for VideoContentAdminService service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
-type VideoContentAdminServiceClient interface {
-	AddNode(ctx context.Context, in *AddNodeRequest, opts ...grpc.CallOption) (*AddNodeResponse, error)
-	RemoveNode(ctx context.Context, in *RemoveNodeRequest, opts ...grpc.CallOption) (*RemoveNodeResponse, error)
-	ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error)
-}
-
-type videoContentAdminServiceClient struct {
-	cc grpc.ClientConnInterface
-}
-
-func NewVideoContentAdminServiceClient(cc grpc.ClientConnInterface) VideoContentAdminServiceClient {
-	return &videoContentAdminServiceClient{cc}
-}
-
-func (c *videoContentAdminServiceClient) AddNode(ctx context.Context, in *AddNodeRequest, opts ...grpc.CallOption) (*AddNodeResponse, error) {
-	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(AddNodeResponse)
-	err := c.cc.Invoke(ctx, VideoContentAdminService_AddNode_FullMethodName, in, out, cOpts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
-}
-
-func (c *videoContentAdminServiceClient) RemoveNode(ctx context.Context, in *RemoveNodeRequest, opts ...grpc.CallOption) (*RemoveNodeResponse, error) {
-	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(RemoveNodeResponse)
-	err := c.cc.Invoke(ctx, VideoContentAdminService_RemoveNode_FullMethodName, in, out, cOpts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
-}
-
-func (c *videoContentAdminServiceClient) ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error) {
-	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(ListNodesResponse)
-	err := c.cc.Invoke(ctx, VideoContentAdminService_ListNodes_FullMethodName, in, out, cOpts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
-}
-
-// VideoContentAdminServiceServer is the server API for VideoContentAdminService service.
-// All implementations must embed UnimplementedVideoContentAdminServiceServer
-// for forward compatibility.
-type VideoContentAdminServiceServer interface {
-	AddNode(context.Context, *AddNodeRequest) (*AddNodeResponse, error)
-	RemoveNode(context.Context, *RemoveNodeRequest) (*RemoveNodeResponse, error)
-	ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error)
-	mustEmbedUnimplementedVideoContentAdminServiceServer()
-}
-
-// UnimplementedVideoContentAdminServiceServer must be embedded to have
-// forward compatible implementations.
-//
-// NOTE: this should be embedded by value instead of pointer to avoid a nil
-// pointer dereference when methods are called.
-type UnimplementedVideoContentAdminServiceServer struct{}
-
-func (UnimplementedVideoContentAdminServiceServer) AddNode(context.Context, *AddNodeRequest) (*AddNodeResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AddNode not implemented")
-}
-func (UnimplementedVideoContentAdminServiceServer) RemoveNode(context.Context, *RemoveNodeRequest) (*RemoveNodeResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method RemoveNode not implemented")
-}
-func (UnimplementedVideoContentAdminServiceServer) ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ListNodes not implemented")
-}
-func (UnimplementedVideoContentAdminServiceServer) mustEmbedUnimplementedVideoContentAdminServiceServer() {
-}
-func (UnimplementedVideoContentAdminServiceServer) testEmbeddedByValue() {}
-
-// UnsafeVideoContentAdminServiceServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to VideoContentAdminServiceServer will
-// result in compilation errors.
-type UnsafeVideoContentAdminServiceServer interface {
-	mustEmbedUnimplementedVideoContentAdminServiceServer()
-}
-
-func RegisterVideoContentAdminServiceServer(s grpc.ServiceRegistrar, srv VideoContentAdminServiceServer) {
-	// If the following call pancis, it indicates UnimplementedVideoContentAdminServiceServer was
-	// embedded by pointer and is nil.  This will cause panics if an
-	// unimplemented method is ever invoked, so we test this at initialization
-	// time to prevent it from happening at runtime later due to I/O.
-	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
-		t.testEmbeddedByValue()
-	}
-	s.RegisterService(&VideoContentAdminService_ServiceDesc, srv)
-}
-
-func _VideoContentAdminService_AddNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(AddNodeRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(VideoContentAdminServiceServer).AddNode(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: VideoContentAdminService_AddNode_FullMethodName,
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(VideoContentAdminServiceServer).AddNode(ctx, req.(*AddNodeRequest))
-	}
-	return interceptor(ctx, in, info, handler)
-}
-
-func _VideoContentAdminService_RemoveNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(RemoveNodeRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(VideoContentAdminServiceServer).RemoveNode(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: VideoContentAdminService_RemoveNode_FullMethodName,
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(VideoContentAdminServiceServer).RemoveNode(ctx, req.(*RemoveNodeRequest))
-	}
-	return interceptor(ctx, in, info, handler)
-}
-
-func _VideoContentAdminService_ListNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ListNodesRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(VideoContentAdminServiceServer).ListNodes(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: VideoContentAdminService_ListNodes_FullMethodName,
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(VideoContentAdminServiceServer).ListNodes(ctx, req.(*ListNodesRequest))
-	}
-	return interceptor(ctx, in, info, handler)
-}
-
-// VideoContentAdminService_ServiceDesc is the grpc.ServiceDesc for VideoContentAdminService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var VideoContentAdminService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "tritontube.VideoContentAdminService",
-	HandlerType: (*VideoContentAdminServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "AddNode",
-			Handler:    _VideoContentAdminService_AddNode_Handler,
-		},
-		{
-			MethodName: "RemoveNode",
-			Handler:    _VideoContentAdminService_RemoveNode_Handler,
-		},
-		{
-			MethodName: "ListNodes",
-			Handler:    _VideoContentAdminService_ListNodes_Handler,
-		},
-	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "proto/admin.proto",
-}
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: proto/admin.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	VideoContentAdminService_AddNode_FullMethodName          = "/tritontube.VideoContentAdminService/AddNode"
+	VideoContentAdminService_RemoveNode_FullMethodName       = "/tritontube.VideoContentAdminService/RemoveNode"
+	VideoContentAdminService_ListNodes_FullMethodName        = "/tritontube.VideoContentAdminService/ListNodes"
+	VideoContentAdminService_DrainNode_FullMethodName        = "/tritontube.VideoContentAdminService/DrainNode"
+	VideoContentAdminService_UndrainNode_FullMethodName      = "/tritontube.VideoContentAdminService/UndrainNode"
+	VideoContentAdminService_MigrateNode_FullMethodName      = "/tritontube.VideoContentAdminService/MigrateNode"
+	VideoContentAdminService_ClusterStatus_FullMethodName    = "/tritontube.VideoContentAdminService/ClusterStatus"
+	VideoContentAdminService_Rebalance_FullMethodName        = "/tritontube.VideoContentAdminService/Rebalance"
+	VideoContentAdminService_RetranscodeVideo_FullMethodName = "/tritontube.VideoContentAdminService/RetranscodeVideo"
+	VideoContentAdminService_RetranscodeAll_FullMethodName   = "/tritontube.VideoContentAdminService/RetranscodeAll"
+	VideoContentAdminService_CollectGarbage_FullMethodName   = "/tritontube.VideoContentAdminService/CollectGarbage"
+)
+
+// VideoContentAdminServiceClient is the client API for VideoContentAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VideoContentAdminServiceClient interface {
+	AddNode(ctx context.Context, in *AddNodeRequest, opts ...grpc.CallOption) (*AddNodeResponse, error)
+	RemoveNode(ctx context.Context, in *RemoveNodeRequest, opts ...grpc.CallOption) (*RemoveNodeResponse, error)
+	ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error)
+	// DrainNode stops new writes to a node and moves its files off it. The node
+	// keeps serving reads until it is removed or brought back with UndrainNode.
+	DrainNode(ctx context.Context, in *DrainNodeRequest, opts ...grpc.CallOption) (*DrainNodeResponse, error)
+	UndrainNode(ctx context.Context, in *UndrainNodeRequest, opts ...grpc.CallOption) (*UndrainNodeResponse, error)
+	// MigrateNode adds, removes, drains or undrains a node like the RPCs above,
+	// streaming progress while files move. Re-issuing an interrupted migration resumes it.
+	MigrateNode(ctx context.Context, in *MigrateNodeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MigrationProgress], error)
+	ClusterStatus(ctx context.Context, in *ClusterStatusRequest, opts ...grpc.CallOption) (*ClusterStatusResponse, error)
+	// Rebalance moves every file that is not on the node the current ring assigns
+	// it to, e.g. after a crash mid-migration, and completes any unfinished migration.
+	Rebalance(ctx context.Context, in *RebalanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MigrationProgress], error)
+	// RetranscodeVideo regenerates a video's DASH files and previews from its
+	// kept original upload. It fails with UNIMPLEMENTED when the web server
+	// doesn't keep originals, and NOT_FOUND when the video has none.
+	RetranscodeVideo(ctx context.Context, in *RetranscodeVideoRequest, opts ...grpc.CallOption) (*Video, error)
+	// RetranscodeAll re-transcodes every video, one at a time, with the web
+	// server's current encoding profile. Each video's new files are stored under
+	// a new version and its manifest is replaced last, so viewers switch over in
+	// one step. Videos without a kept original are skipped, and a video that
+	// fails is reported and left on its old version.
+	RetranscodeAll(ctx context.Context, in *RetranscodeAllRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RetranscodeProgress], error)
+	// CollectGarbage finds stored files whose video has no metadata, such as
+	// those left by failed uploads, crashed migrations or interrupted deletions,
+	// and deletes the ones older than the grace period. Each orphan is streamed
+	// as it is handled. It fails with UNIMPLEMENTED when the content service
+	// can't list its files.
+	CollectGarbage(ctx context.Context, in *CollectGarbageRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GarbageFile], error)
+}
+
+type videoContentAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVideoContentAdminServiceClient(cc grpc.ClientConnInterface) VideoContentAdminServiceClient {
+	return &videoContentAdminServiceClient{cc}
+}
+
+func (c *videoContentAdminServiceClient) AddNode(ctx context.Context, in *AddNodeRequest, opts ...grpc.CallOption) (*AddNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddNodeResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_AddNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) RemoveNode(ctx context.Context, in *RemoveNodeRequest, opts ...grpc.CallOption) (*RemoveNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveNodeResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_RemoveNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListNodesResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_ListNodes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) DrainNode(ctx context.Context, in *DrainNodeRequest, opts ...grpc.CallOption) (*DrainNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DrainNodeResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_DrainNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) UndrainNode(ctx context.Context, in *UndrainNodeRequest, opts ...grpc.CallOption) (*UndrainNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UndrainNodeResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_UndrainNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) MigrateNode(ctx context.Context, in *MigrateNodeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MigrationProgress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VideoContentAdminService_ServiceDesc.Streams[0], VideoContentAdminService_MigrateNode_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MigrateNodeRequest, MigrationProgress]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_MigrateNodeClient = grpc.ServerStreamingClient[MigrationProgress]
+
+func (c *videoContentAdminServiceClient) ClusterStatus(ctx context.Context, in *ClusterStatusRequest, opts ...grpc.CallOption) (*ClusterStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClusterStatusResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_ClusterStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) Rebalance(ctx context.Context, in *RebalanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MigrationProgress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VideoContentAdminService_ServiceDesc.Streams[1], VideoContentAdminService_Rebalance_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RebalanceRequest, MigrationProgress]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_RebalanceClient = grpc.ServerStreamingClient[MigrationProgress]
+
+func (c *videoContentAdminServiceClient) RetranscodeVideo(ctx context.Context, in *RetranscodeVideoRequest, opts ...grpc.CallOption) (*Video, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Video)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_RetranscodeVideo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) RetranscodeAll(ctx context.Context, in *RetranscodeAllRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RetranscodeProgress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VideoContentAdminService_ServiceDesc.Streams[2], VideoContentAdminService_RetranscodeAll_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RetranscodeAllRequest, RetranscodeProgress]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_RetranscodeAllClient = grpc.ServerStreamingClient[RetranscodeProgress]
+
+func (c *videoContentAdminServiceClient) CollectGarbage(ctx context.Context, in *CollectGarbageRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GarbageFile], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VideoContentAdminService_ServiceDesc.Streams[3], VideoContentAdminService_CollectGarbage_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CollectGarbageRequest, GarbageFile]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_CollectGarbageClient = grpc.ServerStreamingClient[GarbageFile]
+
+// VideoContentAdminServiceServer is the server API for VideoContentAdminService service.
+// All implementations must embed UnimplementedVideoContentAdminServiceServer
+// for forward compatibility.
+type VideoContentAdminServiceServer interface {
+	AddNode(context.Context, *AddNodeRequest) (*AddNodeResponse, error)
+	RemoveNode(context.Context, *RemoveNodeRequest) (*RemoveNodeResponse, error)
+	ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error)
+	// DrainNode stops new writes to a node and moves its files off it. The node
+	// keeps serving reads until it is removed or brought back with UndrainNode.
+	DrainNode(context.Context, *DrainNodeRequest) (*DrainNodeResponse, error)
+	UndrainNode(context.Context, *UndrainNodeRequest) (*UndrainNodeResponse, error)
+	// MigrateNode adds, removes, drains or undrains a node like the RPCs above,
+	// streaming progress while files move. Re-issuing an interrupted migration resumes it.
+	MigrateNode(*MigrateNodeRequest, grpc.ServerStreamingServer[MigrationProgress]) error
+	ClusterStatus(context.Context, *ClusterStatusRequest) (*ClusterStatusResponse, error)
+	// Rebalance moves every file that is not on the node the current ring assigns
+	// it to, e.g. after a crash mid-migration, and completes any unfinished migration.
+	Rebalance(*RebalanceRequest, grpc.ServerStreamingServer[MigrationProgress]) error
+	// RetranscodeVideo regenerates a video's DASH files and previews from its
+	// kept original upload. It fails with UNIMPLEMENTED when the web server
+	// doesn't keep originals, and NOT_FOUND when the video has none.
+	RetranscodeVideo(context.Context, *RetranscodeVideoRequest) (*Video, error)
+	// RetranscodeAll re-transcodes every video, one at a time, with the web
+	// server's current encoding profile. Each video's new files are stored under
+	// a new version and its manifest is replaced last, so viewers switch over in
+	// one step. Videos without a kept original are skipped, and a video that
+	// fails is reported and left on its old version.
+	RetranscodeAll(*RetranscodeAllRequest, grpc.ServerStreamingServer[RetranscodeProgress]) error
+	// CollectGarbage finds stored files whose video has no metadata, such as
+	// those left by failed uploads, crashed migrations or interrupted deletions,
+	// and deletes the ones older than the grace period. Each orphan is streamed
+	// as it is handled. It fails with UNIMPLEMENTED when the content service
+	// can't list its files.
+	CollectGarbage(*CollectGarbageRequest, grpc.ServerStreamingServer[GarbageFile]) error
+	mustEmbedUnimplementedVideoContentAdminServiceServer()
+}
+
+// UnimplementedVideoContentAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedVideoContentAdminServiceServer struct{}
+
+func (UnimplementedVideoContentAdminServiceServer) AddNode(context.Context, *AddNodeRequest) (*AddNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddNode not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) RemoveNode(context.Context, *RemoveNodeRequest) (*RemoveNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveNode not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNodes not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) DrainNode(context.Context, *DrainNodeRequest) (*DrainNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DrainNode not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) UndrainNode(context.Context, *UndrainNodeRequest) (*UndrainNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UndrainNode not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) MigrateNode(*MigrateNodeRequest, grpc.ServerStreamingServer[MigrationProgress]) error {
+	return status.Errorf(codes.Unimplemented, "method MigrateNode not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) ClusterStatus(context.Context, *ClusterStatusRequest) (*ClusterStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClusterStatus not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) Rebalance(*RebalanceRequest, grpc.ServerStreamingServer[MigrationProgress]) error {
+	return status.Errorf(codes.Unimplemented, "method Rebalance not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) RetranscodeVideo(context.Context, *RetranscodeVideoRequest) (*Video, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetranscodeVideo not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) RetranscodeAll(*RetranscodeAllRequest, grpc.ServerStreamingServer[RetranscodeProgress]) error {
+	return status.Errorf(codes.Unimplemented, "method RetranscodeAll not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) CollectGarbage(*CollectGarbageRequest, grpc.ServerStreamingServer[GarbageFile]) error {
+	return status.Errorf(codes.Unimplemented, "method CollectGarbage not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) mustEmbedUnimplementedVideoContentAdminServiceServer() {
+}
+func (UnimplementedVideoContentAdminServiceServer) testEmbeddedByValue() {}
+
+// UnsafeVideoContentAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VideoContentAdminServiceServer will
+// result in compilation errors.
+type UnsafeVideoContentAdminServiceServer interface {
+	mustEmbedUnimplementedVideoContentAdminServiceServer()
+}
+
+func RegisterVideoContentAdminServiceServer(s grpc.ServiceRegistrar, srv VideoContentAdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedVideoContentAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&VideoContentAdminService_ServiceDesc, srv)
+}
+
+func _VideoContentAdminService_AddNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).AddNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_AddNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).AddNode(ctx, req.(*AddNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_RemoveNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).RemoveNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_RemoveNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).RemoveNode(ctx, req.(*RemoveNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_ListNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).ListNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_ListNodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).ListNodes(ctx, req.(*ListNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_DrainNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).DrainNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_DrainNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).DrainNode(ctx, req.(*DrainNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_UndrainNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndrainNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).UndrainNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_UndrainNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).UndrainNode(ctx, req.(*UndrainNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_MigrateNode_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(MigrateNodeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VideoContentAdminServiceServer).MigrateNode(m, &grpc.GenericServerStream[MigrateNodeRequest, MigrationProgress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_MigrateNodeServer = grpc.ServerStreamingServer[MigrationProgress]
+
+func _VideoContentAdminService_ClusterStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClusterStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).ClusterStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_ClusterStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).ClusterStatus(ctx, req.(*ClusterStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_Rebalance_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RebalanceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VideoContentAdminServiceServer).Rebalance(m, &grpc.GenericServerStream[RebalanceRequest, MigrationProgress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_RebalanceServer = grpc.ServerStreamingServer[MigrationProgress]
+
+func _VideoContentAdminService_RetranscodeVideo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetranscodeVideoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).RetranscodeVideo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_RetranscodeVideo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).RetranscodeVideo(ctx, req.(*RetranscodeVideoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_RetranscodeAll_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RetranscodeAllRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VideoContentAdminServiceServer).RetranscodeAll(m, &grpc.GenericServerStream[RetranscodeAllRequest, RetranscodeProgress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_RetranscodeAllServer = grpc.ServerStreamingServer[RetranscodeProgress]
+
+func _VideoContentAdminService_CollectGarbage_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CollectGarbageRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VideoContentAdminServiceServer).CollectGarbage(m, &grpc.GenericServerStream[CollectGarbageRequest, GarbageFile]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_CollectGarbageServer = grpc.ServerStreamingServer[GarbageFile]
+
+// VideoContentAdminService_ServiceDesc is the grpc.ServiceDesc for VideoContentAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VideoContentAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tritontube.VideoContentAdminService",
+	HandlerType: (*VideoContentAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddNode",
+			Handler:    _VideoContentAdminService_AddNode_Handler,
+		},
+		{
+			MethodName: "RemoveNode",
+			Handler:    _VideoContentAdminService_RemoveNode_Handler,
+		},
+		{
+			MethodName: "ListNodes",
+			Handler:    _VideoContentAdminService_ListNodes_Handler,
+		},
+		{
+			MethodName: "DrainNode",
+			Handler:    _VideoContentAdminService_DrainNode_Handler,
+		},
+		{
+			MethodName: "UndrainNode",
+			Handler:    _VideoContentAdminService_UndrainNode_Handler,
+		},
+		{
+			MethodName: "ClusterStatus",
+			Handler:    _VideoContentAdminService_ClusterStatus_Handler,
+		},
+		{
+			MethodName: "RetranscodeVideo",
+			Handler:    _VideoContentAdminService_RetranscodeVideo_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "MigrateNode",
+			Handler:       _VideoContentAdminService_MigrateNode_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Rebalance",
+			Handler:       _VideoContentAdminService_Rebalance_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RetranscodeAll",
+			Handler:       _VideoContentAdminService_RetranscodeAll_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "CollectGarbage",
+			Handler:       _VideoContentAdminService_CollectGarbage_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/admin.proto",
+}
@@ -0,0 +1,188 @@
+// Transport security and authentication for the storage and admin gRPC services
+
+package rpcauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TokenEnv is read for the shared token when -auth-token is not given, which
+// keeps the token out of the process list.
+const TokenEnv = "TRITONTUBE_AUTH_TOKEN"
+
+// Config is the security setup of one process. The same certificate is used
+// when serving and, as a client certificate, when dialing other processes.
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// CAFile verifies peers. On a server it also makes client certificates mandatory.
+	CAFile string
+	// Token, if set, must be presented by clients as "authorization: Bearer <token>".
+	Token string
+}
+
+// Flags registers -tls-cert, -tls-key, -tls-ca and -auth-token on fs.
+func Flags(fs *flag.FlagSet) *Config {
+	c := &Config{}
+	fs.StringVar(&c.CertFile, "tls-cert", "", "PEM certificate for gRPC TLS")
+	fs.StringVar(&c.KeyFile, "tls-key", "", "PEM private key for -tls-cert")
+	fs.StringVar(&c.CAFile, "tls-ca", "", "PEM CA bundle for verifying gRPC peers (servers then require client certificates)")
+	fs.StringVar(&c.Token, "auth-token", "", "Shared token for gRPC calls (default $"+TokenEnv+")")
+	return c
+}
+
+func (c *Config) token() string {
+	if c.Token != "" {
+		return c.Token
+	}
+	return os.Getenv(TokenEnv)
+}
+
+func (c *Config) certificates() ([]tls.Certificate, error) {
+	if c.CertFile == "" && c.KeyFile == "" {
+		return nil, nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, errors.New("-tls-cert and -tls-key must be given together")
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return []tls.Certificate{cert}, nil
+}
+
+func (c *Config) caPool() (*x509.CertPool, error) {
+	if c.CAFile == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", c.CAFile)
+	}
+	return pool, nil
+}
+
+// AuthenticatesClients reports whether a server set up with ServerOptions only
+// accepts known clients, i.e. a token or a CA for client certificates is
+// configured. TLS alone encrypts calls but lets anyone make them.
+func (c *Config) AuthenticatesClients() bool {
+	return c.token() != "" || c.CAFile != ""
+}
+
+// ServerOptions returns the options for a gRPC server: TLS when a certificate is
+// configured, client certificate verification when a CA is, and a token check
+// when a token is.
+func (c *Config) ServerOptions() ([]grpc.ServerOption, error) {
+	certs, err := c.certificates()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := c.caPool()
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []grpc.ServerOption
+	if certs != nil {
+		tlsConfig := &tls.Config{Certificates: certs, MinVersion: tls.VersionTLS12}
+		if pool != nil {
+			tlsConfig.ClientCAs = pool
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	} else if pool != nil {
+		return nil, errors.New("-tls-ca on a server needs -tls-cert and -tls-key")
+	}
+
+	if token := c.token(); token != "" {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				if err := checkToken(ctx, token); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if err := checkToken(ss.Context(), token); err != nil {
+					return err
+				}
+				return handler(srv, ss)
+			}),
+		)
+	}
+	return opts, nil
+}
+
+// DialOptions returns the options for dialing a server set up with the matching
+// ServerOptions: TLS when a CA or certificate is configured, and the token if there is one.
+func (c *Config) DialOptions() ([]grpc.DialOption, error) {
+	certs, err := c.certificates()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := c.caPool()
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []grpc.DialOption
+	secure := certs != nil || pool != nil
+	if secure {
+		// a nil pool verifies against the system roots
+		tlsConfig := &tls.Config{Certificates: certs, RootCAs: pool, MinVersion: tls.VersionTLS12}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if token := c.token(); token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials{token: token, secure: secure}))
+	}
+	return opts, nil
+}
+
+func checkToken(ctx context.Context, token string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		given, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid auth token")
+}
+
+// tokenCredentials attaches the shared token to every call.
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+// RequireTransportSecurity is false without TLS so the token mode also works on
+// trusted networks where setting up certificates isn't worth it.
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
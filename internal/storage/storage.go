@@ -1,5 +1,154 @@
-// Lab 8: Implement a network video content service (server)
-
-package storage
-
-// Implement a network video content service (server)
+// Lab 8: Implement a network video content service (server)
+
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"tritontube/internal/fsutil"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MaxMessageSize bounds a single gRPC message between web servers and storage
+// nodes. DASH segments are sent whole, so the default 4MB limit is too small.
+const MaxMessageSize = 64 << 20
+
+// StorageServer stores video files for one node of the cluster under baseDir/<videoId>/<filename>.
+type StorageServer struct {
+	proto.UnimplementedVideoContentStorageServiceServer
+
+	baseDir string
+}
+
+// Constructor
+func NewStorageServer(baseDir string) *StorageServer {
+	return &StorageServer{baseDir: baseDir}
+}
+
+// filePath rejects ids and filenames that would escape baseDir.
+func (s *StorageServer) filePath(videoId string, filename string) (string, error) {
+	for _, part := range []string{videoId, filename} {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `/\`) {
+			return "", status.Errorf(codes.InvalidArgument, "invalid path component %q", part)
+		}
+	}
+	return filepath.Join(s.baseDir, videoId, filename), nil
+}
+
+// READ
+func (s *StorageServer) Read(ctx context.Context, req *proto.ReadRequest) (*proto.ReadResponse, error) {
+	path, err := s.filePath(req.VideoId, req.Filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, status.Errorf(codes.NotFound, "%s/%s not found", req.VideoId, req.Filename)
+	} else if err != nil {
+		return nil, err
+	}
+	return &proto.ReadResponse{Data: data}, nil
+}
+
+// WRITE
+func (s *StorageServer) Write(ctx context.Context, req *proto.WriteRequest) (*proto.WriteResponse, error) {
+	path, err := s.filePath(req.VideoId, req.Filename)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = fsutil.WriteFileAtomic(path, req.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.WriteResponse{}, nil
+}
+
+// DELETE
+func (s *StorageServer) Delete(ctx context.Context, req *proto.DeleteRequest) (*proto.DeleteResponse, error) {
+	path, err := s.filePath(req.VideoId, req.Filename)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	// drop the video directory once its last file is gone
+	os.Remove(filepath.Dir(path))
+	return &proto.DeleteResponse{}, nil
+}
+
+// STAT
+func (s *StorageServer) Stat(ctx context.Context, req *proto.StatRequest) (*proto.StatResponse, error) {
+	path, err := s.filePath(req.VideoId, req.Filename)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, status.Errorf(codes.NotFound, "%s/%s not found", req.VideoId, req.Filename)
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.StatResponse{Size: size, Sha256: hash.Sum(nil)}, nil
+}
+
+// LIST
+func (s *StorageServer) List(ctx context.Context, req *proto.ListRequest) (*proto.ListResponse, error) {
+	videoDirs, err := os.ReadDir(s.baseDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return &proto.ListResponse{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var files []*proto.FileKey
+	for _, videoDir := range videoDirs {
+		if !videoDir.IsDir() || (req.VideoId != "" && videoDir.Name() != req.VideoId) {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(s.baseDir, videoDir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			// dot files are writes in progress
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, &proto.FileKey{
+				VideoId:    videoDir.Name(),
+				Filename:   entry.Name(),
+				Size:       info.Size(),
+				ModifiedAt: timestamppb.New(info.ModTime()),
+			})
+		}
+	}
+	return &proto.ListResponse{Files: files}, nil
+}
+
+var _ proto.VideoContentStorageServiceServer = (*StorageServer)(nil)
@@ -0,0 +1,59 @@
+// gRPC admin API: storage cluster membership, and maintenance of the web server's videos
+
+package web
+
+import (
+	"context"
+	"time"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc"
+)
+
+// AdminService serves proto.VideoContentAdminService. The cluster RPCs are
+// answered by the network content service; the others work on the videos of
+// the web server it wraps, whatever its content service is.
+type AdminService struct {
+	// cluster answers AddNode, MigrateNode and the other cluster RPCs
+	proto.VideoContentAdminServiceServer
+	s *server
+}
+
+// Constructor
+//
+// cluster is nil when the content service is not a storage cluster; its RPCs
+// then fail with UNIMPLEMENTED.
+func NewAdminService(s *server, cluster proto.VideoContentAdminServiceServer) *AdminService {
+	if cluster == nil {
+		cluster = proto.UnimplementedVideoContentAdminServiceServer{}
+	}
+	return &AdminService{VideoContentAdminServiceServer: cluster, s: s}
+}
+
+// RETRANSCODE
+func (a *AdminService) RetranscodeVideo(ctx context.Context, req *proto.RetranscodeVideoRequest) (*proto.Video, error) {
+	video, err := a.s.retranscode(ctx, req.Id)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return a.s.protoVideo(video), nil
+}
+
+// RETRANSCODE ALL
+func (a *AdminService) RetranscodeAll(req *proto.RetranscodeAllRequest, stream grpc.ServerStreamingServer[proto.RetranscodeProgress]) error {
+	if err := a.s.retranscodeAll(stream.Context(), req.DryRun, stream.Send); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+// COLLECT GARBAGE
+func (a *AdminService) CollectGarbage(req *proto.CollectGarbageRequest, stream grpc.ServerStreamingServer[proto.GarbageFile]) error {
+	grace := time.Duration(req.GracePeriodSeconds) * time.Second
+	if err := a.s.collectGarbage(req.DryRun, grace, stream.Send); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+var _ proto.VideoContentAdminServiceServer = (*AdminService)(nil)
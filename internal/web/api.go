@@ -0,0 +1,355 @@
+// JSON API for scripts and the mobile client
+
+package web
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// apiVideo is the JSON form of a video.
+type apiVideo struct {
+	Id         string     `json:"id"`
+	UploadedAt time.Time  `json:"uploaded_at"`
+	Uploader   string     `json:"uploader"`
+	Visibility Visibility `json:"visibility"`
+	// AudioLanguages has the language of each audio track, "und" when untagged
+	AudioLanguages []string `json:"audio_languages"`
+	// PageURL is the HTML player page
+	PageURL string `json:"page_url"`
+	// ManifestURL is a signed DASH manifest URL, valid for a limited time
+	ManifestURL string `json:"manifest_url"`
+	// StorageBytes is what the video's files and kept original take up. It is
+	// only given to the uploader, and absent when the video's usage isn't tracked.
+	StorageBytes *int64 `json:"storage_bytes,omitempty"`
+}
+
+type apiVideoList struct {
+	Videos []apiVideo `json:"videos"`
+	Total  int        `json:"total"`
+	// NextOffset is the offset of the next page, or absent on the last page
+	NextOffset *int `json:"next_offset,omitempty"`
+}
+
+// apiVideoPatch is the body of PATCH /api/videos/{id}; absent fields are left alone.
+type apiVideoPatch struct {
+	Visibility *Visibility `json:"visibility"`
+}
+
+// apiError is the body of every failed API request:
+//
+//	{"error": {"code": "not_found", "message": "video not found"}}
+type apiError struct {
+	Error apiErrorBody `json:"error"`
+}
+
+type apiErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Limit is set when code is limit_exceeded or quota_exceeded
+	Limit *apiLimit `json:"limit,omitempty"`
+	// Job is the id of the upload's job, when the upload got far enough to have one
+	Job string `json:"job,omitempty"`
+	// DuplicateOf is the id of the video a duplicate upload was rejected for
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+}
+
+// apiLimit says which upload limit was exceeded.
+type apiLimit struct {
+	// Name is one of "file size", "duration", "resolution", "frame rate" and "storage quota"
+	Name    string  `json:"name"`
+	Maximum float64 `json:"maximum"`
+	// Actual is absent when the upload was cut off at the limit
+	Actual float64 `json:"actual,omitempty"`
+	Unit   string  `json:"unit"`
+}
+
+// openAPISpec documents apiRoutes and the JSON types above. Keep them in step;
+// openapi_test.go fails when they drift apart.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+type apiRoute struct {
+	method  string
+	pattern string
+	handler func(s *server, w http.ResponseWriter, r *http.Request)
+}
+
+var apiRoutes = []apiRoute{
+	{http.MethodGet, "/api/openapi.json", (*server).handleAPISpec},
+	{http.MethodGet, "/api/videos", (*server).handleAPIListVideos},
+	{http.MethodPost, "/api/videos", (*server).handleAPICreateVideo},
+	{http.MethodGet, "/api/videos/{id}", (*server).handleAPIGetVideo},
+	{http.MethodPatch, "/api/videos/{id}", (*server).handleAPIUpdateVideo},
+	{http.MethodDelete, "/api/videos/{id}", (*server).handleAPIDeleteVideo},
+	{http.MethodGet, "/api/videos/{id}/subtitles", (*server).handleAPIListSubtitles},
+	{http.MethodPut, "/api/videos/{id}/subtitles/{language}", (*server).handleAPIPutSubtitle},
+	{http.MethodDelete, "/api/videos/{id}/subtitles/{language}", (*server).handleAPIDeleteSubtitle},
+	{http.MethodGet, "/api/jobs", (*server).handleAPIListJobs},
+	{http.MethodGet, "/api/jobs/{id}", (*server).handleAPIGetJob},
+	{http.MethodDelete, "/api/jobs/{id}", (*server).handleAPICancelJob},
+	{http.MethodGet, "/api/usage", (*server).handleAPIUsage},
+}
+
+func (s *server) registerAPI() {
+	allowed := make(map[string][]string)
+	for _, route := range apiRoutes {
+		s.mux.HandleFunc(route.method+" "+route.pattern, func(w http.ResponseWriter, r *http.Request) {
+			route.handler(s, w, r)
+		})
+		allowed[route.pattern] = append(allowed[route.pattern], route.method)
+		if route.method == http.MethodGet {
+			allowed[route.pattern] = append(allowed[route.pattern], http.MethodHead)
+		}
+	}
+	// a known path with another method gets a JSON 405 instead of ServeMux's plain text one
+	for pattern, methods := range allowed {
+		allow := strings.Join(methods, ", ")
+		s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allow)
+			writeAPIError(w, &requestError{http.StatusMethodNotAllowed, "method_not_allowed", r.Method + " is not allowed here; use " + allow})
+		})
+	}
+	// anything else under /api/ gets a JSON 404 instead of the index page
+	s.mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, &requestError{http.StatusNotFound, "not_found", "no such API endpoint"})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, err error) {
+	status, code := errorStatus(err)
+	body := apiErrorBody{Code: code, Message: err.Error()}
+	var limitErr *limitError
+	if errors.As(err, &limitErr) {
+		body.Limit = &apiLimit{Name: limitErr.limit, Maximum: limitErr.maximum, Actual: limitErr.actual, Unit: limitErr.unit}
+	}
+	var failedJob *jobError
+	if errors.As(err, &failedJob) {
+		body.Job = failedJob.jobId
+	}
+	var duplicate *duplicateError
+	if errors.As(err, &duplicate) {
+		body.DuplicateOf = duplicate.videoId
+	}
+	writeJSON(w, status, apiError{Error: body})
+}
+
+// apiVideo converts video to its JSON form. storageBytes is only given to its
+// uploader, see ownVideoUsage.
+func (s *server) apiVideo(video *VideoMetadata, storageBytes *int64) apiVideo {
+	return apiVideo{
+		Id:             video.Id,
+		UploadedAt:     video.UploadedAt,
+		Uploader:       video.Uploader,
+		Visibility:     video.Visibility,
+		AudioLanguages: append([]string{}, video.AudioLanguages...),
+		PageURL:        "/videos/" + url.PathEscape(video.Id),
+		ManifestURL:    s.signer.contentURL(video.Id, versionedFilename(video.Version, manifestFilename), time.Now()),
+		StorageBytes:   storageBytes,
+	}
+}
+
+// findVideo returns the video named by the request path, or a not_found error
+// when it doesn't exist or user can't see it.
+func (s *server) findVideo(r *http.Request, user string) (*VideoMetadata, error) {
+	video, err := s.metadataService.Read(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+	if video == nil || !canView(video, user) {
+		return nil, &requestError{http.StatusNotFound, "not_found", "video not found"}
+	}
+	return video, nil
+}
+
+// findOwnVideo is findVideo for changes, which only the uploader may make.
+func (s *server) findOwnVideo(r *http.Request) (*VideoMetadata, error) {
+	user := s.currentUser(r)
+	if user == "" {
+		return nil, &requestError{http.StatusUnauthorized, "unauthenticated", "log in to change videos"}
+	}
+	video, err := s.findVideo(r, user)
+	if err != nil {
+		return nil, err
+	}
+	if video.Uploader != user {
+		return nil, &requestError{http.StatusForbidden, "forbidden", "only the uploader can change this video"}
+	}
+	return video, nil
+}
+
+// queryInt parses an optional non-negative integer query parameter.
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, &requestError{http.StatusBadRequest, "invalid_parameter", name + " must be a non-negative integer"}
+	}
+	return n, nil
+}
+
+// GET /api/openapi.json
+func (s *server) handleAPISpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// GET /api/videos?limit=&offset= lists the videos the index page would show, newest first.
+func (s *server) handleAPIListVideos(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", defaultPageSize)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	videos, err := s.metadataService.List()
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	user := s.currentUser(r)
+	videos = slices.DeleteFunc(videos, func(video VideoMetadata) bool {
+		return !isListed(&video, user)
+	})
+	sortNewestFirst(videos)
+
+	list := apiVideoList{Videos: []apiVideo{}, Total: len(videos)}
+	if offset < len(videos) {
+		page := videos[offset:min(offset+limit, len(videos))]
+		usage := s.ownVideosUsage(user)
+		for i := range page {
+			var storageBytes *int64
+			if used, ok := usage[page[i].Id]; ok {
+				storageBytes = &used
+			}
+			list.Videos = append(list.Videos, s.apiVideo(&page[i], storageBytes))
+		}
+		if next := offset + len(page); next < len(videos) {
+			list.NextOffset = &next
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// sortNewestFirst orders videos by upload time, then id, so pages are stable.
+func sortNewestFirst(videos []VideoMetadata) {
+	slices.SortFunc(videos, func(a, b VideoMetadata) int {
+		if c := b.UploadedAt.Compare(a.UploadedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Id, b.Id)
+	})
+}
+
+// POST /api/videos takes the same multipart form as /upload.
+func (s *server) handleAPICreateVideo(w http.ResponseWriter, r *http.Request) {
+	uploader := s.currentUser(r)
+	if uploader == "" {
+		writeAPIError(w, &requestError{http.StatusUnauthorized, "unauthenticated", "log in to upload videos"})
+		return
+	}
+	video, err := s.ingestUpload(r, uploader)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/api/videos/"+url.PathEscape(video.Id))
+	writeJSON(w, http.StatusCreated, s.apiVideo(video, s.ownVideoUsage(video, uploader)))
+}
+
+// GET /api/videos/{id}
+func (s *server) handleAPIGetVideo(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	video, err := s.findVideo(r, user)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.apiVideo(video, s.ownVideoUsage(video, user)))
+}
+
+// PATCH /api/videos/{id}
+func (s *server) handleAPIUpdateVideo(w http.ResponseWriter, r *http.Request) {
+	video, err := s.findOwnVideo(r)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	var patch apiVideoPatch
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		writeAPIError(w, &requestError{http.StatusBadRequest, "invalid_body", err.Error()})
+		return
+	}
+	if patch.Visibility != nil {
+		visibility, ok := ParseVisibility(string(*patch.Visibility))
+		if !ok {
+			writeAPIError(w, &requestError{http.StatusBadRequest, "invalid_visibility", "visibility must be public, unlisted or private"})
+			return
+		}
+		// only the patched field is written, so a re-transcode finishing meanwhile isn't undone
+		if err := s.metadataService.SetVisibility(video.Id, visibility); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+
+	video, err = s.metadataService.Read(video.Id)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if video == nil {
+		writeAPIError(w, &requestError{http.StatusNotFound, "not_found", "video not found"})
+		return
+	}
+	// only the uploader gets this far
+	writeJSON(w, http.StatusOK, s.apiVideo(video, s.ownVideoUsage(video, video.Uploader)))
+}
+
+// DELETE /api/videos/{id}
+func (s *server) handleAPIDeleteVideo(w http.ResponseWriter, r *http.Request) {
+	video, err := s.findOwnVideo(r)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if err := s.deleteVideo(video.Id); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,242 @@
+// User registration, login and sessions for the web UI
+
+package web
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sessionCookieName = "tritontube_session"
+	sessionDuration   = 7 * 24 * time.Hour
+
+	passwordIterations = 600000
+	minPasswordLength  = 8
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,32}$`)
+
+// hashPassword returns a self-describing PBKDF2-SHA256 hash: "pbkdf2-sha256$<iterations>$<salt>$<key>".
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, 32)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func checkPassword(password string, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// hashSessionToken is what gets stored, so a leaked database holds no usable sessions.
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// currentUser returns the username of the logged-in user, or "" for anonymous requests.
+// API clients may send the session token as "Authorization: Bearer <token>" instead of the cookie.
+func (s *server) currentUser(r *http.Request) string {
+	if s.users == nil {
+		return ""
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		cookie, err := r.Cookie(sessionCookieName)
+		if err != nil {
+			return ""
+		}
+		token = cookie.Value
+	}
+	session, err := s.users.ReadSession(hashSessionToken(token))
+	if err != nil || session == nil {
+		return ""
+	}
+	return session.Username
+}
+
+// isSecureRequest reports whether the client reached us over HTTPS, directly or through a proxy.
+func isSecureRequest(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+func (s *server) startSession(w http.ResponseWriter, r *http.Request, username string) error {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	expiresAt := time.Now().Add(sessionDuration)
+	err := s.users.CreateSession(hashSessionToken(token), Session{Username: username, ExpiresAt: expiresAt})
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+func (s *server) renderAuthPage(w http.ResponseWriter, page string, status int, errorMessage string) {
+	tmpl := template.Must(template.New("auth").Parse(authHTML))
+	w.WriteHeader(status)
+	tmpl.Execute(w, struct {
+		Title  string
+		Action string
+		Error  string
+	}{
+		Title:  page,
+		Action: "/" + strings.ToLower(page),
+		Error:  errorMessage,
+	})
+}
+
+func (s *server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if s.users == nil {
+		http.Error(w, "accounts are not supported by this metadata store", http.StatusNotImplemented)
+		return
+	}
+	if r.Method == http.MethodGet {
+		s.renderAuthPage(w, "Register", http.StatusOK, "")
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	username := r.FormValue("username")
+	password := r.FormValue("password")
+	if !usernamePattern.MatchString(username) {
+		s.renderAuthPage(w, "Register", http.StatusBadRequest, "Usernames are 3-32 letters, digits, '_' or '-'.")
+		return
+	}
+	if len(password) < minPasswordLength {
+		s.renderAuthPage(w, "Register", http.StatusBadRequest, fmt.Sprintf("Passwords need at least %d characters.", minPasswordLength))
+		return
+	}
+	existing, err := s.users.ReadUser(username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if existing != nil {
+		s.renderAuthPage(w, "Register", http.StatusConflict, "That username is taken.")
+		return
+	}
+
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	err = s.users.CreateUser(User{Username: username, PasswordHash: passwordHash, CreatedAt: time.Now()})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := s.startSession(w, r, username); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if s.users == nil {
+		http.Error(w, "accounts are not supported by this metadata store", http.StatusNotImplemented)
+		return
+	}
+	if r.Method == http.MethodGet {
+		s.renderAuthPage(w, "Login", http.StatusOK, "")
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	username := r.FormValue("username")
+	user, err := s.users.ReadUser(username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if user == nil || !checkPassword(r.FormValue("password"), user.PasswordHash) {
+		s.renderAuthPage(w, "Login", http.StatusUnauthorized, "Wrong username or password.")
+		return
+	}
+	if err := s.startSession(w, r, user.Username); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if cookie, err := r.Cookie(sessionCookieName); err == nil && s.users != nil {
+		if err := s.users.DeleteSession(hashSessionToken(cookie.Value)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
@@ -0,0 +1,215 @@
+// Content-addressed storage: identical files are stored once and reference counted
+
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// blobVideoId is where blobs are kept in the underlying content service, as
+// if they were the files of one video. Video ids can't start with a dot, so it
+// can't clash with a real video.
+const blobVideoId = ".blobs"
+
+// DedupVideoContentService implements VideoContentService on top of another
+// one, storing each distinct file content once, named by its SHA-256. The
+// index maps video files to blobs and counts references, and a blob is deleted
+// with its last reference. Files written before deduplication was enabled are
+// still read and deleted from where they are.
+type DedupVideoContentService struct {
+	blobs VideoContentService
+	index BlobIndex
+
+	// mu orders index changes with blob deletions; writing keeps blobs that
+	// are being written from being deleted by a concurrent unlink
+	mu      sync.Mutex
+	writing map[string]int
+}
+
+// Constructor
+func NewDedupVideoContentService(blobs VideoContentService, index BlobIndex) *DedupVideoContentService {
+	return &DedupVideoContentService{blobs: blobs, index: index, writing: make(map[string]int)}
+}
+
+// blobHash names data in the blob store.
+func blobHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// WRITE
+func (d *DedupVideoContentService) Write(videoId string, filename string, data []byte) error {
+	hash := blobHash(data)
+
+	d.mu.Lock()
+	d.writing[hash]++
+	refs, err := d.index.BlobRefs(hash)
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		if d.writing[hash]--; d.writing[hash] == 0 {
+			delete(d.writing, hash)
+		}
+		d.mu.Unlock()
+	}()
+	if err != nil {
+		return err
+	}
+
+	if refs == 0 {
+		if err := d.blobs.Write(blobVideoId, hash, data); err != nil {
+			return err
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// a file that is overwritten lets go of its old blob, e.g. a subtitle track
+	// being replaced, without a moment where readers can't find it
+	oldHash, oldRefs, err := d.index.LinkBlob(videoId, filename, hash, int64(len(data)))
+	if err != nil {
+		return err
+	}
+	d.release(oldHash, oldRefs)
+	return nil
+}
+
+// unlink removes a file from the index, and its blob if nothing else uses it.
+// d.mu must be held.
+func (d *DedupVideoContentService) unlink(videoId string, filename string) error {
+	hash, refs, err := d.index.UnlinkBlob(videoId, filename)
+	if err != nil {
+		return err
+	}
+	d.release(hash, refs)
+	return nil
+}
+
+// release deletes a blob left with no references, unless a write is about to
+// link it again. d.mu must be held.
+func (d *DedupVideoContentService) release(hash string, refs int) {
+	if hash == "" || refs > 0 || d.writing[hash] > 0 {
+		return
+	}
+	// the file is gone either way; a blob that fails to delete is only wasted space
+	if err := d.blobs.DeleteFile(blobVideoId, hash); err != nil {
+		log.Printf("Deleting blob %s: %v", hash, err)
+	}
+}
+
+// READ
+func (d *DedupVideoContentService) Read(videoId string, filename string) ([]byte, error) {
+	hash, err := d.index.BlobOf(videoId, filename)
+	if err != nil {
+		return nil, err
+	}
+	if hash == "" {
+		return d.blobs.Read(videoId, filename)
+	}
+	return d.blobs.Read(blobVideoId, hash)
+}
+
+// DELETE
+func (d *DedupVideoContentService) Delete(videoId string) error {
+	filenames, err := d.index.ListBlobFiles(videoId)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	for _, filename := range filenames {
+		if err := d.unlink(videoId, filename); err != nil {
+			d.mu.Unlock()
+			return err
+		}
+	}
+	d.mu.Unlock()
+	return d.blobs.Delete(videoId)
+}
+
+// DELETE FILE
+//
+// Blobs, as listed by ListAll, are only deleted while nothing uses them.
+func (d *DedupVideoContentService) DeleteFile(videoId string, filename string) error {
+	if videoId == blobVideoId {
+		return d.deleteUnusedBlob(filename)
+	}
+	d.mu.Lock()
+	err := d.unlink(videoId, filename)
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return d.blobs.DeleteFile(videoId, filename)
+}
+
+// deleteUnusedBlob deletes a blob that no file links to and no write is about to.
+func (d *DedupVideoContentService) deleteUnusedBlob(hash string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	refs, err := d.index.BlobRefs(hash)
+	if err != nil || refs > 0 || d.writing[hash] > 0 {
+		return err
+	}
+	return d.blobs.DeleteFile(blobVideoId, hash)
+}
+
+// LIST FILES
+//
+// Files written before deduplication was enabled are listed too.
+func (d *DedupVideoContentService) ListFiles(videoId string) ([]ContentFile, error) {
+	lister, ok := d.blobs.(ContentLister)
+	if !ok {
+		return nil, fmt.Errorf("the underlying content service can't list files")
+	}
+	files, err := d.index.ListLinkedFiles(videoId)
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := lister.ListFiles(videoId)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, legacy...), nil
+}
+
+// LIST ALL
+//
+// Besides the files of videos, blobs that no file links to are listed under
+// blobVideoId, e.g. ones written by an upload that failed before linking them.
+func (d *DedupVideoContentService) ListAll() ([]ContentFile, error) {
+	lister, ok := d.blobs.(ContentLister)
+	if !ok {
+		return nil, fmt.Errorf("the underlying content service can't list files")
+	}
+	files, err := d.index.ListLinkedFiles("")
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := lister.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, legacy...)
+
+	blobs, err := lister.ListFiles(blobVideoId)
+	if err != nil {
+		return nil, err
+	}
+	for _, blob := range blobs {
+		refs, err := d.index.BlobRefs(blob.Filename)
+		if err != nil {
+			return nil, err
+		}
+		if refs == 0 {
+			files = append(files, blob)
+		}
+	}
+	return files, nil
+}
+
+var _ VideoContentService = (*DedupVideoContentService)(nil)
+var _ ContentLister = (*DedupVideoContentService)(nil)
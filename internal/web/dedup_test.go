@@ -0,0 +1,215 @@
+package web
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestDedup returns a deduplicating content service over a temporary
+// directory, indexed by a temporary SQLite database.
+func newTestDedup(t *testing.T) (*DedupVideoContentService, *FSVideoContentService, *SQLiteVideoMetadataService) {
+	t.Helper()
+	index, err := NewSQLiteVideoMetadataService(filepath.Join(t.TempDir(), "metadata.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs := NewFSVideoContentService(t.TempDir())
+	return NewDedupVideoContentService(blobs, index), blobs, index
+}
+
+// storedBlobs returns the contents of the blobs on disk.
+func storedBlobs(t *testing.T, blobs *FSVideoContentService) []string {
+	t.Helper()
+	files, err := blobs.ListFiles(blobVideoId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, file := range files {
+		data, err := blobs.Read(blobVideoId, file.Filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(data))
+	}
+	slices.Sort(contents)
+	return contents
+}
+
+func TestDedupRefcounts(t *testing.T) {
+	type step struct {
+		op       string // write, delete or deleteFile
+		videoId  string
+		filename string
+		data     string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		// reads maps "videoId/filename" to its content, or "" when it must be gone
+		reads map[string]string
+		// blobs are the contents stored once each afterwards
+		blobs []string
+	}{
+		{
+			name: "identical files share a blob",
+			steps: []step{
+				{"write", "a", "seg1", "same"},
+				{"write", "b", "seg1", "same"},
+				{"write", "b", "seg2", "same"},
+			},
+			reads: map[string]string{"a/seg1": "same", "b/seg1": "same", "b/seg2": "same"},
+			blobs: []string{"same"},
+		},
+		{
+			name: "deleting a video keeps blobs another video uses",
+			steps: []step{
+				{"write", "a", "seg1", "same"},
+				{"write", "a", "seg2", "only a"},
+				{"write", "b", "seg1", "same"},
+				{"delete", "a", "", ""},
+			},
+			reads: map[string]string{"a/seg1": "", "a/seg2": "", "b/seg1": "same"},
+			blobs: []string{"same"},
+		},
+		{
+			name: "last reference deletes the blob",
+			steps: []step{
+				{"write", "a", "seg1", "same"},
+				{"write", "b", "seg1", "same"},
+				{"delete", "a", "", ""},
+				{"deleteFile", "b", "seg1", ""},
+			},
+			reads: map[string]string{"a/seg1": "", "b/seg1": ""},
+			blobs: nil,
+		},
+		{
+			name: "overwrite while another video shares the blob",
+			steps: []step{
+				{"write", "a", "subtitles-en.vtt", "old"},
+				{"write", "b", "subtitles-en.vtt", "old"},
+				{"write", "a", "subtitles-en.vtt", "new"},
+			},
+			reads: map[string]string{"a/subtitles-en.vtt": "new", "b/subtitles-en.vtt": "old"},
+			blobs: []string{"new", "old"},
+		},
+		{
+			name: "overwrite drops an unshared blob",
+			steps: []step{
+				{"write", "a", "subtitles-en.vtt", "old"},
+				{"write", "a", "subtitles-en.vtt", "new"},
+			},
+			reads: map[string]string{"a/subtitles-en.vtt": "new"},
+			blobs: []string{"new"},
+		},
+		{
+			name: "rewriting the same content keeps the blob",
+			steps: []step{
+				{"write", "a", "seg1", "same"},
+				{"write", "a", "seg1", "same"},
+			},
+			reads: map[string]string{"a/seg1": "same"},
+			blobs: []string{"same"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dedup, blobs, _ := newTestDedup(t)
+			for _, step := range test.steps {
+				var err error
+				switch step.op {
+				case "write":
+					err = dedup.Write(step.videoId, step.filename, []byte(step.data))
+				case "delete":
+					err = dedup.Delete(step.videoId)
+				case "deleteFile":
+					err = dedup.DeleteFile(step.videoId, step.filename)
+				}
+				if err != nil {
+					t.Fatalf("%s %s/%s: %v", step.op, step.videoId, step.filename, err)
+				}
+			}
+
+			for path, want := range test.reads {
+				videoId, filename, _ := strings.Cut(path, "/")
+				data, err := dedup.Read(videoId, filename)
+				if want == "" {
+					if err == nil {
+						t.Errorf("read %s = %q, want it gone", path, data)
+					}
+				} else if err != nil || string(data) != want {
+					t.Errorf("read %s = %q, %v, want %q", path, data, err, want)
+				}
+			}
+			if got := storedBlobs(t, blobs); !slices.Equal(got, test.blobs) {
+				t.Errorf("stored blobs = %q, want %q", got, test.blobs)
+			}
+		})
+	}
+}
+
+// Files written before deduplication was enabled are read and deleted where they are.
+func TestDedupLegacyFiles(t *testing.T) {
+	dedup, blobs, _ := newTestDedup(t)
+	if err := blobs.Write("a", "manifest.mpd", []byte("legacy")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := dedup.Read("a", "manifest.mpd")
+	if err != nil || string(data) != "legacy" {
+		t.Fatalf("read legacy file = %q, %v", data, err)
+	}
+	if err := dedup.DeleteFile("a", "manifest.mpd"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := blobs.Read("a", "manifest.mpd"); err == nil {
+		t.Error("legacy file was not deleted")
+	}
+}
+
+// Garbage collection sees blobs nothing links to, and can't delete linked ones.
+func TestDedupUnusedBlobs(t *testing.T) {
+	dedup, blobs, index := newTestDedup(t)
+	if err := dedup.Write("a", "seg1", []byte("used")); err != nil {
+		t.Fatal(err)
+	}
+	// a blob left by an upload that failed before linking it
+	if err := blobs.Write(blobVideoId, blobHash([]byte("orphan")), []byte("orphan")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := dedup.ListAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed []string
+	for _, file := range files {
+		listed = append(listed, file.VideoId+"/"+file.Filename)
+	}
+	slices.Sort(listed)
+	if want := []string{blobVideoId + "/" + blobHash([]byte("orphan")), "a/seg1"}; !slices.Equal(listed, want) {
+		t.Errorf("ListAll = %q, want %q", listed, want)
+	}
+
+	// a blob in use is kept even when asked for directly
+	if err := dedup.DeleteFile(blobVideoId, blobHash([]byte("used"))); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := dedup.Read("a", "seg1"); err != nil || string(data) != "used" {
+		t.Errorf("read a/seg1 after deleting its blob = %q, %v", data, err)
+	}
+
+	for _, file := range files {
+		if err := dedup.DeleteFile(file.VideoId, file.Filename); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := storedBlobs(t, blobs); len(got) != 0 {
+		t.Errorf("stored blobs after deleting everything = %q", got)
+	}
+	if refs, err := index.BlobRefs(blobHash([]byte("used"))); err != nil || refs != 0 {
+		t.Errorf("references to a deleted file's blob = %d, %v", refs, err)
+	}
+}
@@ -1,9 +1,9 @@
-// Lab 9: Implement a distributed video metadata service
-
-package web
-
-type EtcdVideoMetadataService struct {
-}
-
-// Uncomment the following line to ensure EtcdVideoMetadataService implements VideoMetadataService
-// var _ VideoMetadataService = (*EtcdVideoMetadataService)(nil)
+// Lab 9: Implement a distributed video metadata service
+
+package web
+
+type EtcdVideoMetadataService struct {
+}
+
+// Uncomment the following line to ensure EtcdVideoMetadataService implements VideoMetadataService
+// var _ VideoMetadataService = (*EtcdVideoMetadataService)(nil)
@@ -3,8 +3,11 @@
 package web
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
+	"tritontube/internal/fsutil"
 )
 
 // FSVideoContentService implements VideoContentService using the local filesystem.
@@ -25,7 +28,7 @@ func (fs *FSVideoContentService) Write(videoId string, filename string, data []b
 		return err
 	}
 	filePath := filepath.Join(dirPath, filename)
-	return os.WriteFile(filePath, data, 0644)
+	return fsutil.WriteFileAtomic(filePath, data)
 }
 
 // READ
@@ -34,5 +37,67 @@ func (fs *FSVideoContentService) Read(videoId string, filename string) ([]byte,
 	return os.ReadFile(filePath)
 }
 
+// DELETE
+func (fs *FSVideoContentService) Delete(videoId string) error {
+	return os.RemoveAll(filepath.Join(fs.baseDir, videoId))
+}
+
+// DELETE FILE
+func (fs *FSVideoContentService) DeleteFile(videoId string, filename string) error {
+	err := os.Remove(filepath.Join(fs.baseDir, videoId, filename))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// LIST FILES
+func (fs *FSVideoContentService) ListFiles(videoId string) ([]ContentFile, error) {
+	entries, err := os.ReadDir(filepath.Join(fs.baseDir, videoId))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var files []ContentFile
+	for _, entry := range entries {
+		// dot files are writes in progress
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			continue // deleted since
+		} else if err != nil {
+			return nil, err
+		}
+		files = append(files, ContentFile{VideoId: videoId, Filename: entry.Name(), Size: info.Size(), ModifiedAt: info.ModTime()})
+	}
+	return files, nil
+}
+
+// LIST ALL
+func (fs *FSVideoContentService) ListAll() ([]ContentFile, error) {
+	videoDirs, err := os.ReadDir(fs.baseDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var files []ContentFile
+	for _, videoDir := range videoDirs {
+		if !videoDir.IsDir() || strings.HasPrefix(videoDir.Name(), ".") {
+			continue
+		}
+		videoFiles, err := fs.ListFiles(videoDir.Name())
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, videoFiles...)
+	}
+	return files, nil
+}
+
 // Uncomment the following line to ensure FSVideoContentService implements VideoContentService
 var _ VideoContentService = (*FSVideoContentService)(nil)
+var _ ContentLister = (*FSVideoContentService)(nil)
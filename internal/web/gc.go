@@ -0,0 +1,114 @@
+// Garbage collection of stored files whose video no longer exists
+
+package web
+
+import (
+	"log"
+	"net/http"
+	"time"
+	"tritontube/internal/proto"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultGCGracePeriod is how old an orphaned file must be before it is
+// deleted. Uploads store their files before the video's metadata, so newer
+// files may belong to an upload that is still in progress.
+const DefaultGCGracePeriod = 24 * time.Hour
+
+// collectGarbage reports every stored file, in the content service and among
+// kept originals, whose video has no metadata. Unless dryRun, the ones
+// modified more than grace ago are deleted; a grace of 0 uses the configured one.
+func (s *server) collectGarbage(dryRun bool, grace time.Duration, report func(*proto.GarbageFile) error) error {
+	lister, ok := s.contentService.(ContentLister)
+	if !ok {
+		return &requestError{http.StatusNotImplemented, "not_implemented", "the content service can't list its files"}
+	}
+	if grace <= 0 {
+		grace = s.gcGrace
+	}
+
+	// content is listed before metadata, so a video created in between counts as existing
+	files, err := lister.ListAll()
+	if err != nil {
+		return err
+	}
+	var originals []ContentFile
+	if originalsLister, ok := s.originals.(ContentLister); ok {
+		originals, err = originalsLister.ListAll()
+		if err != nil {
+			return err
+		}
+	}
+	videos, err := s.metadataService.List()
+	if err != nil {
+		return err
+	}
+	exists := make(map[string]bool, len(videos))
+	for _, video := range videos {
+		exists[video.Id] = true
+	}
+
+	cutoff := time.Now().Add(-grace)
+	collect := func(store VideoContentService, file ContentFile, original bool) error {
+		if exists[file.VideoId] {
+			return nil
+		}
+		garbage := &proto.GarbageFile{
+			VideoId:    file.VideoId,
+			Filename:   file.Filename,
+			Size:       file.Size,
+			ModifiedAt: timestamppb.New(file.ModifiedAt),
+			Original:   original,
+		}
+		if !dryRun && file.ModifiedAt.Before(cutoff) {
+			if err := store.DeleteFile(file.VideoId, file.Filename); err != nil {
+				garbage.Error = err.Error()
+			} else {
+				garbage.Deleted = true
+			}
+		}
+		return report(garbage)
+	}
+	for _, file := range files {
+		if err := collect(s.contentService, file, false); err != nil {
+			return err
+		}
+	}
+	for _, file := range originals {
+		if err := collect(s.originals, file, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectGarbageEvery runs collectGarbage every interval until the process
+// exits, logging what it deleted.
+func (s *server) collectGarbageEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		var deleted, kept, failed int
+		var deletedBytes int64
+		err := s.collectGarbage(false, 0, func(file *proto.GarbageFile) error {
+			switch {
+			case file.Deleted:
+				deleted++
+				deletedBytes += file.Size
+			case file.Error != "":
+				failed++
+				log.Printf("Deleting orphaned file %s/%s: %s", file.VideoId, file.Filename, file.Error)
+			default:
+				kept++
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Collecting garbage: %v", err)
+			continue
+		}
+		if deleted+kept+failed > 0 {
+			log.Printf("Collected garbage: deleted %d orphaned files (%d bytes), kept %d within the grace period, %d failed",
+				deleted, deletedBytes, kept, failed)
+		}
+	}
+}
@@ -1,19 +1,226 @@
-package web
-
-import "time"
-
-type VideoMetadata struct {
-	Id         string
-	UploadedAt time.Time
-}
-
-type VideoMetadataService interface {
-	Read(id string) (*VideoMetadata, error)
-	List() ([]VideoMetadata, error)
-	Create(videoId string, uploadedAt time.Time) error
-}
-
-type VideoContentService interface {
-	Read(videoId string, filename string) ([]byte, error)
-	Write(videoId string, filename string, data []byte) error
-}
+package web
+
+import "time"
+
+type VideoMetadata struct {
+	Id         string
+	UploadedAt time.Time
+	// Uploader is the username of the account that uploaded the video.
+	Uploader   string
+	Visibility Visibility
+	// AudioLanguages has the ISO 639 language of each audio track, in manifest
+	// order, with "und" for untagged tracks.
+	AudioLanguages []string
+	// SourceHash is the hex SHA-256 of the uploaded file, used to spot duplicate uploads.
+	SourceHash string
+	// Version names the current transcode of the video; its files are stored
+	// as "<version>-<name>". Videos transcoded before versions existed have "".
+	Version string
+}
+
+// Visibility controls who can find and watch a video.
+type Visibility string
+
+const (
+	// VisibilityPublic videos are listed on the index page.
+	VisibilityPublic Visibility = "public"
+	// VisibilityUnlisted videos can be watched by anyone with the link.
+	VisibilityUnlisted Visibility = "unlisted"
+	// VisibilityPrivate videos can only be watched by their uploader.
+	VisibilityPrivate Visibility = "private"
+)
+
+// ParseVisibility accepts the values above; "" means public.
+func ParseVisibility(s string) (Visibility, bool) {
+	switch v := Visibility(s); v {
+	case "":
+		return VisibilityPublic, true
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+		return v, true
+	}
+	return "", false
+}
+
+type VideoMetadataService interface {
+	Read(id string) (*VideoMetadata, error)
+	List() ([]VideoMetadata, error)
+	Create(video VideoMetadata) error
+	// SetVisibility changes only the visibility of video id.
+	SetVisibility(id string, visibility Visibility) error
+	// SetVersion makes to, transcoded with audioLanguages, the version of video
+	// id if its version is still from. It reports whether it was, so a change
+	// made during a long transcode is neither undone nor overwritten.
+	SetVersion(id string, from string, to string, audioLanguages []string) (bool, error)
+	Delete(id string) error
+}
+
+type VideoContentService interface {
+	Read(videoId string, filename string) ([]byte, error)
+	Write(videoId string, filename string, data []byte) error
+	// Delete removes every file of a video. Deleting a video with no files is not an error.
+	Delete(videoId string) error
+	// DeleteFile removes one file of a video. Deleting a missing file is not an error.
+	DeleteFile(videoId string, filename string) error
+}
+
+// ContentFile is a stored file, as listed by a ContentLister.
+type ContentFile struct {
+	VideoId    string
+	Filename   string
+	Size       int64
+	ModifiedAt time.Time
+}
+
+// ContentLister is implemented by content services that can list what they
+// store, which is needed to delete old versions of a video and orphaned files.
+type ContentLister interface {
+	// ListFiles returns the files of videoId.
+	ListFiles(videoId string) ([]ContentFile, error)
+	// ListAll returns the files of every video. Ids starting with a dot are not
+	// videos but the service's own; their files are only listed when they are
+	// unused and can be deleted like orphans.
+	ListAll() ([]ContentFile, error)
+}
+
+// Subtitle is a caption track of a video, stored as WebVTT next to its DASH files.
+type Subtitle struct {
+	// Language is a BCP 47 tag such as "en" or "pt-BR"; a video has at most one track per language.
+	Language string
+	// Label is what the player's caption menu shows.
+	Label string
+}
+
+// SubtitleService stores which subtitle tracks each video has. Metadata
+// services that also implement it enable subtitle uploads.
+type SubtitleService interface {
+	// SetSubtitle adds a track, or replaces the one with the same language.
+	SetSubtitle(videoId string, subtitle Subtitle) error
+	ListSubtitles(videoId string) ([]Subtitle, error)
+	DeleteSubtitle(videoId string, language string) error
+}
+
+// BlobIndex maps video files to content-addressed blobs and counts the
+// references to each blob. Metadata services that also implement it can back
+// a DedupVideoContentService.
+type BlobIndex interface {
+	// LinkBlob records that videoId/filename has the content of the blob with
+	// the given hash, adding a reference to the blob. A file that is already
+	// linked lets go of its old blob in the same step, so it is never missing;
+	// the old blob's hash and remaining references are returned, or "" when
+	// the file wasn't linked.
+	LinkBlob(videoId string, filename string, hash string, size int64) (oldHash string, oldRefs int, err error)
+	// UnlinkBlob forgets videoId/filename. It returns the hash of its blob and
+	// how many references the blob has left, or "" when the file wasn't linked.
+	UnlinkBlob(videoId string, filename string) (hash string, refs int, err error)
+	// BlobOf returns the hash of the blob of videoId/filename, or "" when it isn't linked.
+	BlobOf(videoId string, filename string) (string, error)
+	// BlobRefs returns how many files use the blob; 0 means it isn't stored.
+	BlobRefs(hash string) (int, error)
+	// ListBlobFiles returns the linked filenames of a video.
+	ListBlobFiles(videoId string) ([]string, error)
+	// ListLinkedFiles returns the linked files of videoId, or of every video when
+	// it is "", with the size of their blob and when they were linked as their
+	// modification time.
+	ListLinkedFiles(videoId string) ([]ContentFile, error)
+}
+
+// JobState is how far a transcode job has got.
+type JobState string
+
+const (
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// JobRecord is the history of one upload's transcode job.
+type JobRecord struct {
+	Id       string
+	VideoId  string
+	Uploader string
+	State    JobState
+	// Progress is the percentage of the video transcoded, from 0 to 100.
+	Progress float64
+	// Error says why a failed or cancelled job ended.
+	Error string
+	// Log is the end of ffmpeg's output.
+	Log        string
+	StartedAt  time.Time
+	FinishedAt time.Time // zero while running
+}
+
+// JobService stores transcode job records. Metadata services that also
+// implement it keep upload logs and progress across restarts.
+type JobService interface {
+	// SaveJob creates a record, or replaces the one with the same id. Saving
+	// a finished job forgets the uploader's oldest finished jobs beyond
+	// maxJobsPerUploader.
+	SaveJob(job JobRecord) error
+	ReadJob(id string) (*JobRecord, error)
+	// ListJobs returns up to limit of uploader's jobs, newest first.
+	ListJobs(uploader string, limit int) ([]JobRecord, error)
+}
+
+// RetiredVersion is a transcode of a video that has been replaced. Its files
+// are kept for a while for viewers who still have its manifest.
+type RetiredVersion struct {
+	VideoId   string
+	Version   string
+	RetiredAt time.Time
+}
+
+// VersionService keeps track of retired versions until their files are deleted.
+// Metadata services that implement it keep retired versions across restarts.
+type VersionService interface {
+	RetireVersion(version RetiredVersion) error
+	// ListRetiredVersions returns the versions retired before the given time, oldest first.
+	ListRetiredVersions(before time.Time) ([]RetiredVersion, error)
+	// ForgetVersion removes a retired version once its files are deleted.
+	ForgetVersion(videoId string, version string) error
+}
+
+// UsageService records the size of every stored file, so the storage used by a
+// video and by an uploader can be reported and capped. Store tells apart the
+// content services sizes come from, e.g. "content" and "originals". When the
+// metadata service implements it, the server records sizes on every write.
+type UsageService interface {
+	// SetFileSize records the size of a file, replacing its previous size.
+	SetFileSize(store string, videoId string, filename string, size int64) error
+	DeleteFileSize(store string, videoId string, filename string) error
+	// DeleteVideoSizes forgets the sizes of every file of videoId in store.
+	DeleteVideoSizes(store string, videoId string) error
+	// VideoUsage returns the bytes stored for videoId, across stores. Tracked
+	// is false when no sizes are recorded for it, e.g. for a video stored
+	// before sizes were recorded whose sizes haven't been backfilled yet.
+	VideoUsage(videoId string) (used int64, tracked bool, err error)
+	// UploaderUsage returns the bytes stored for the videos of uploader, across stores.
+	UploaderUsage(uploader string) (int64, error)
+	// UploaderVideoUsage returns the bytes stored for each of uploader's videos
+	// that has sizes recorded, across stores.
+	UploaderVideoUsage(uploader string) (map[string]int64, error)
+	// ListTrackedVideos returns the ids of the videos with sizes recorded in store.
+	ListTrackedVideos(store string) ([]string, error)
+}
+
+type User struct {
+	Username     string
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+type Session struct {
+	Username  string
+	ExpiresAt time.Time
+}
+
+// UserService stores accounts and login sessions. Metadata services that also
+// implement it enable registration and login in the web UI.
+type UserService interface {
+	CreateUser(user User) error
+	ReadUser(username string) (*User, error)
+	// Sessions are keyed by a hash of the cookie token, never the token itself.
+	CreateSession(tokenHash string, session Session) error
+	ReadSession(tokenHash string) (*Session, error)
+	DeleteSession(tokenHash string) error
+}
@@ -0,0 +1,551 @@
+// Transcode jobs: time limits, cancellation, resource limits, logs and progress for ffmpeg
+
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTranscodeTimeout bounds the ffmpeg work for one upload.
+	DefaultTranscodeTimeout = 2 * time.Hour
+	// killedProcessWait is how long to wait for a killed process's output pipes to close
+	killedProcessWait = 10 * time.Second
+	// maxJobLog is how much of the end of ffmpeg's output a job keeps
+	maxJobLog = 64 << 10
+	// progress is saved at most this often while a job runs; live progress is read from memory
+	jobSaveInterval = 5 * time.Second
+	// recentJobs is how many of a user's jobs the index page shows
+	recentJobs = 5
+	// maxMemoryJobs bounds the records kept when the metadata store has no JobService
+	maxMemoryJobs = 1000
+	// maxJobsPerUploader is how many of an uploader's finished jobs are kept, logs included
+	maxJobsPerUploader = 100
+)
+
+var (
+	errJobCancelled  = &requestError{http.StatusConflict, "cancelled", "the upload was cancelled"}
+	errJobTimedOut   = &requestError{http.StatusUnprocessableEntity, "transcode_timeout", "transcoding took longer than the server allows"}
+	errJobInProgress = &requestError{http.StatusConflict, "already_exists", "a video with this id is already being uploaded"}
+	errJobNotFound   = &requestError{http.StatusNotFound, "not_found", "no such job"}
+	errJobNotRunning = &requestError{http.StatusConflict, "not_running", "the job has already finished"}
+)
+
+// jobError is an upload that failed after its job started, so its record has the details.
+type jobError struct {
+	jobId string
+	err   error
+}
+
+func (e *jobError) Error() string {
+	return e.err.Error()
+}
+
+func (e *jobError) Unwrap() error {
+	return e.err
+}
+
+// tailBuffer keeps the last maxJobLog bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > maxJobLog {
+		t.buf = slices.Clone(t.buf[len(t.buf)-maxJobLog:])
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
+
+// lastLines returns up to n of the last non-empty lines, joined by "; ".
+// ffmpeg prints the reason it failed at the end of its output.
+func (t *tailBuffer) lastLines(n int) string {
+	var lines []string
+	for _, line := range strings.Split(t.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines[max(len(lines)-n, 0):], "; ")
+}
+
+// transcodeJob is a running upload, from being accepted to its video being created.
+type transcodeJob struct {
+	mu        sync.Mutex
+	record    JobRecord
+	lastSaved time.Time
+	log       tailBuffer
+
+	registry *jobRegistry
+	// threads and nice limit the job's ffmpeg processes; 0 means no limit
+	threads int
+	nice    int
+	cancel  context.CancelCauseFunc
+	stop    context.CancelFunc
+}
+
+// command runs name (ffmpeg or ffprobe) for the job. The process is killed
+// when ctx ends, runs at the job's nice level, and ffmpeg is held to the job's
+// thread count. The output file must be the last argument. Output goes to the
+// job's log.
+func (j *transcodeJob) command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	if name == "ffmpeg" && j.threads > 0 && len(args) > 0 {
+		threads := strconv.Itoa(j.threads)
+		last := len(args) - 1
+		args = append([]string{"-filter_threads", threads}, args...)
+		args = slices.Insert(args, last+2, "-threads", threads)
+	}
+	if j.nice > 0 {
+		// nice execs the command, so cancelling still kills the process itself
+		args = append([]string{"-n", strconv.Itoa(j.nice), name}, args...)
+		name = "nice"
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &j.log
+	cmd.Stderr = &j.log
+	// don't hang on output pipes held open by a killed process's children
+	cmd.WaitDelay = killedProcessWait
+	return cmd
+}
+
+// snapshot returns the job's record with its log so far.
+func (j *transcodeJob) snapshot() JobRecord {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	record := j.record
+	record.Log = j.log.String()
+	return record
+}
+
+// setProgress records the percentage transcoded, saving it now and then.
+func (j *transcodeJob) setProgress(percent float64) {
+	j.mu.Lock()
+	j.record.Progress = percent
+	save := time.Since(j.lastSaved) >= jobSaveInterval
+	if save {
+		j.lastSaved = time.Now()
+	}
+	j.mu.Unlock()
+	if save {
+		j.registry.save(j.snapshot())
+	}
+}
+
+// jobRegistry runs transcode jobs and keeps their records.
+type jobRegistry struct {
+	mu sync.Mutex
+	// running jobs by id
+	jobs    map[string]*transcodeJob
+	store   JobService
+	timeout time.Duration
+	threads int
+	nice    int
+}
+
+// Constructor
+func newJobRegistry(store JobService, config Config) *jobRegistry {
+	timeout := config.TranscodeTimeout
+	if timeout <= 0 {
+		timeout = DefaultTranscodeTimeout
+	}
+	return &jobRegistry{
+		jobs:    make(map[string]*transcodeJob),
+		store:   store,
+		timeout: timeout,
+		threads: config.TranscodeThreads,
+		nice:    config.TranscodeNice,
+	}
+}
+
+// save stores a record. Failing to is logged rather than failing the upload.
+func (r *jobRegistry) save(record JobRecord) {
+	if err := r.store.SaveJob(record); err != nil {
+		log.Printf("Saving job %s: %v", record.Id, err)
+	}
+}
+
+// start registers a job for videoId. The returned context ends when ctx does,
+// when the job is cancelled, or when the transcode timeout passes; its cause
+// says which. Call finish when the job is done.
+func (r *jobRegistry) start(ctx context.Context, videoId string, uploader string) (*transcodeJob, context.Context, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.Lock()
+	for _, job := range r.jobs {
+		if job.record.VideoId == videoId {
+			r.mu.Unlock()
+			return nil, nil, errJobInProgress
+		}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	ctx, stop := context.WithTimeoutCause(ctx, r.timeout, errJobTimedOut)
+	job := &transcodeJob{
+		record: JobRecord{
+			Id:        hex.EncodeToString(raw),
+			VideoId:   videoId,
+			Uploader:  uploader,
+			State:     JobRunning,
+			StartedAt: time.Now(),
+		},
+		lastSaved: time.Now(),
+		registry:  r,
+		threads:   r.threads,
+		nice:      r.nice,
+		cancel:    cancel,
+		stop:      stop,
+	}
+	r.jobs[job.record.Id] = job
+	r.mu.Unlock()
+
+	r.save(job.snapshot())
+	return job, ctx, nil
+}
+
+// finish records how job ended, err being its result, and releases its context.
+func (r *jobRegistry) finish(job *transcodeJob, err error) {
+	job.mu.Lock()
+	job.record.FinishedAt = time.Now()
+	switch {
+	case err == nil:
+		job.record.State = JobSucceeded
+		job.record.Progress = 100
+	case errors.Is(err, errJobCancelled):
+		job.record.State = JobCancelled
+		job.record.Error = err.Error()
+	default:
+		job.record.State = JobFailed
+		job.record.Error = err.Error()
+	}
+	job.mu.Unlock()
+	r.save(job.snapshot())
+
+	r.mu.Lock()
+	delete(r.jobs, job.record.Id)
+	r.mu.Unlock()
+	job.stop()
+	job.cancel(context.Canceled)
+}
+
+// cancel stops a running job of uploader. Other users' jobs are reported as not found.
+func (r *jobRegistry) cancel(id string, uploader string) error {
+	r.mu.Lock()
+	job, ok := r.jobs[id]
+	r.mu.Unlock()
+	if !ok {
+		if _, err := r.read(id, uploader); err != nil {
+			return err
+		}
+		return errJobNotRunning
+	}
+	if job.record.Uploader != uploader {
+		return errJobNotFound
+	}
+	job.cancel(errJobCancelled)
+	return nil
+}
+
+// live returns the record of a job running here, with its current progress and log.
+func (r *jobRegistry) live(id string) (JobRecord, bool) {
+	r.mu.Lock()
+	job, ok := r.jobs[id]
+	r.mu.Unlock()
+	if !ok {
+		return JobRecord{}, false
+	}
+	return job.snapshot(), true
+}
+
+// running returns the record of the job running here for videoId.
+func (r *jobRegistry) running(videoId string) (JobRecord, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, job := range r.jobs {
+		if job.record.VideoId == videoId {
+			return job.snapshot(), true
+		}
+	}
+	return JobRecord{}, false
+}
+
+// interrupted marks a record saved as running by a server that stopped before finishing it.
+func interrupted(record *JobRecord) {
+	record.State = JobFailed
+	record.Error = "the server stopped before the job finished"
+}
+
+// read returns uploader's job with the given id.
+func (r *jobRegistry) read(id string, uploader string) (*JobRecord, error) {
+	if record, ok := r.live(id); ok {
+		if record.Uploader != uploader {
+			return nil, errJobNotFound
+		}
+		return &record, nil
+	}
+	record, err := r.store.ReadJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil || record.Uploader != uploader {
+		return nil, errJobNotFound
+	}
+	if record.State == JobRunning {
+		interrupted(record)
+	}
+	return record, nil
+}
+
+// list returns up to limit of uploader's jobs, newest first.
+func (r *jobRegistry) list(uploader string, limit int) ([]JobRecord, error) {
+	records, err := r.store.ListJobs(uploader, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		if records[i].State != JobRunning {
+			continue
+		}
+		if record, ok := r.live(records[i].Id); ok {
+			records[i] = record
+		} else {
+			interrupted(&records[i])
+		}
+	}
+	return records, nil
+}
+
+// memoryJobStore keeps job records until the server restarts, for metadata
+// stores without a JobService.
+type memoryJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]JobRecord
+}
+
+// Constructor
+func newMemoryJobStore() *memoryJobStore {
+	return &memoryJobStore{jobs: make(map[string]JobRecord)}
+}
+
+// SAVE JOB
+func (m *memoryJobStore) SaveJob(job JobRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[job.Id] = job
+	if job.State != JobRunning {
+		m.pruneUploader(job.Uploader)
+	}
+	if len(m.jobs) > maxMemoryJobs {
+		// forget the oldest finished job
+		oldest := ""
+		for id, record := range m.jobs {
+			if record.State != JobRunning && (oldest == "" || record.StartedAt.Before(m.jobs[oldest].StartedAt)) {
+				oldest = id
+			}
+		}
+		delete(m.jobs, oldest)
+	}
+	return nil
+}
+
+// pruneUploader forgets uploader's oldest finished jobs beyond maxJobsPerUploader.
+func (m *memoryJobStore) pruneUploader(uploader string) {
+	var finished []JobRecord
+	for _, record := range m.jobs {
+		if record.Uploader == uploader && record.State != JobRunning {
+			finished = append(finished, record)
+		}
+	}
+	if len(finished) <= maxJobsPerUploader {
+		return
+	}
+	slices.SortFunc(finished, func(a, b JobRecord) int { return b.StartedAt.Compare(a.StartedAt) })
+	for _, record := range finished[maxJobsPerUploader:] {
+		delete(m.jobs, record.Id)
+	}
+}
+
+// READ JOB
+func (m *memoryJobStore) ReadJob(id string) (*JobRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+// LIST JOBS
+func (m *memoryJobStore) ListJobs(uploader string, limit int) ([]JobRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var jobs []JobRecord
+	for _, job := range m.jobs {
+		if job.Uploader == uploader {
+			jobs = append(jobs, job)
+		}
+	}
+	slices.SortFunc(jobs, func(a, b JobRecord) int { return b.StartedAt.Compare(a.StartedAt) })
+	return jobs[:min(limit, len(jobs))], nil
+}
+
+var _ JobService = (*memoryJobStore)(nil)
+
+// handleJob shows a job's progress, and its error and ffmpeg log once it has ended.
+func (s *server) handleJob(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	if user == "" {
+		http.Error(w, "log in to see your uploads", http.StatusUnauthorized)
+		return
+	}
+	job, err := s.jobs.read(r.PathValue("id"), user)
+	if err != nil {
+		status, _ := errorStatus(err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	data := struct {
+		JobRecord
+		StartedAt      string
+		EscapedVideoId string
+		Running        bool
+	}{
+		JobRecord:      *job,
+		StartedAt:      job.StartedAt.Format("2006-01-02 15:04:05"),
+		EscapedVideoId: url.PathEscape(job.VideoId),
+		Running:        job.State == JobRunning,
+	}
+	tmpl := template.Must(template.New("job").Parse(jobHTML))
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleCancelJob handles the cancel buttons on the index and job pages.
+func (s *server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	if user == "" {
+		http.Error(w, "log in to cancel uploads", http.StatusUnauthorized)
+		return
+	}
+	if err := s.jobs.cancel(r.PathValue("id"), user); err != nil {
+		status, _ := errorStatus(err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	http.Redirect(w, r, "/jobs/"+r.PathValue("id"), http.StatusSeeOther)
+}
+
+// apiJob is the JSON form of a transcode job.
+type apiJob struct {
+	Id       string   `json:"id"`
+	VideoId  string   `json:"video_id"`
+	State    JobState `json:"state"`
+	Progress float64  `json:"progress"`
+	Error    string   `json:"error,omitempty"`
+	// Log is only included when a single job is requested
+	Log        string     `json:"log,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func newAPIJob(job *JobRecord, withLog bool) apiJob {
+	a := apiJob{
+		Id:        job.Id,
+		VideoId:   job.VideoId,
+		State:     job.State,
+		Progress:  job.Progress,
+		Error:     job.Error,
+		StartedAt: job.StartedAt,
+	}
+	if withLog {
+		a.Log = job.Log
+	}
+	if !job.FinishedAt.IsZero() {
+		a.FinishedAt = &job.FinishedAt
+	}
+	return a
+}
+
+// GET /api/jobs?limit= lists the caller's uploads, newest first.
+func (s *server) handleAPIListJobs(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	if user == "" {
+		writeAPIError(w, &requestError{http.StatusUnauthorized, "unauthenticated", "log in to see your uploads"})
+		return
+	}
+	limit, err := queryInt(r, "limit", defaultPageSize)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	jobs, err := s.jobs.list(user, min(limit, maxPageSize))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	list := []apiJob{}
+	for i := range jobs {
+		list = append(list, newAPIJob(&jobs[i], false))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// GET /api/jobs/{id}
+func (s *server) handleAPIGetJob(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	if user == "" {
+		writeAPIError(w, &requestError{http.StatusUnauthorized, "unauthenticated", "log in to see your uploads"})
+		return
+	}
+	job, err := s.jobs.read(r.PathValue("id"), user)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newAPIJob(job, true))
+}
+
+// DELETE /api/jobs/{id} cancels one of the caller's running uploads.
+func (s *server) handleAPICancelJob(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	if user == "" {
+		writeAPIError(w, &requestError{http.StatusUnauthorized, "unauthenticated", "log in to cancel uploads"})
+		return
+	}
+	if err := s.jobs.cancel(r.PathValue("id"), user); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
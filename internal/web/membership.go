@@ -0,0 +1,283 @@
+// Persistent cluster membership for the network video content service
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"tritontube/internal/fsutil"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// ClusterState is what a MembershipStore records: the nodes on the ring and the
+// membership change whose file migration has not finished yet, if any.
+type ClusterState struct {
+	Nodes []string `json:"nodes"`
+	// Draining nodes are still members and serve reads, but take no new writes.
+	Draining  []string   `json:"draining,omitempty"`
+	Migration *Migration `json:"migration,omitempty"`
+}
+
+// Migration identifies an unfinished membership change so it can be resumed.
+type Migration struct {
+	Action string `json:"action"` // "add", "remove", "drain" or "undrain"
+	Node   string `json:"node"`
+}
+
+// ErrMembershipConflict is returned by MembershipStore.Save when another web
+// server saved membership since the given revision.
+var ErrMembershipConflict = errors.New("cluster membership was changed by another server")
+
+// MembershipStore durably records which storage nodes make up the ring, so that
+// it survives restarts and every web server routes with the same view. Saves
+// are compare-and-swap on a revision, so concurrent changes made through
+// different web servers can't overwrite each other.
+type MembershipStore interface {
+	// Load returns the stored state and its revision, or nil and 0 if nothing has been stored yet.
+	Load() (*ClusterState, int64, error)
+	// Save stores state if the stored state is still at revision, and returns
+	// the new revision. It fails with ErrMembershipConflict otherwise.
+	Save(state *ClusterState, revision int64) (int64, error)
+	// Watch calls onChange with the new state and its revision every time it changes, until ctx is done.
+	Watch(ctx context.Context, onChange func(state *ClusterState, revision int64))
+}
+
+const (
+	// membershipLockWait is how long a save waits for another server's save to the same file
+	membershipLockWait = 5 * time.Second
+	// staleMembershipLock is how old a lock file must be to be taken over from a crashed server
+	staleMembershipLock = 30 * time.Second
+)
+
+// FileMembershipStore keeps membership in a local JSON file. Web servers sharing
+// the file pick up each other's changes by polling it, and take turns saving
+// with a lock file next to it.
+type FileMembershipStore struct {
+	path         string
+	pollInterval time.Duration
+}
+
+// fileMembership is what the membership file holds; the revision counts saves.
+type fileMembership struct {
+	ClusterState
+	Revision int64 `json:"revision"`
+}
+
+// fileRevision returns the revision of a membership file, 0 for files written
+// before revisions were recorded.
+func fileRevision(data []byte) int64 {
+	var file struct {
+		Revision int64 `json:"revision"`
+	}
+	json.Unmarshal(data, &file) // a bare node list has no revision
+	return file.Revision
+}
+
+// Constructor
+func NewFileMembershipStore(path string) *FileMembershipStore {
+	return &FileMembershipStore{path: path, pollInterval: time.Second}
+}
+
+// LOAD
+func (f *FileMembershipStore) Load() (*ClusterState, int64, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+	state, err := decodeMembership(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return state, fileRevision(data), nil
+}
+
+// SAVE
+func (f *FileMembershipStore) Save(state *ClusterState, revision int64) (int64, error) {
+	unlock, err := f.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	_, stored, err := f.Load()
+	if err != nil {
+		return 0, err
+	}
+	if stored != revision {
+		return 0, ErrMembershipConflict
+	}
+	data, err := json.Marshal(fileMembership{ClusterState: *state, Revision: revision + 1})
+	if err != nil {
+		return 0, err
+	}
+	// a concurrent reader sees the old file or the new one, never a partial one
+	if err := fsutil.WriteFileAtomic(f.path, data); err != nil {
+		return 0, err
+	}
+	return revision + 1, nil
+}
+
+// lock creates the lock file, waiting while another server holds it, and
+// returns a function that removes it.
+func (f *FileMembershipStore) lock() (func(), error) {
+	lockPath := f.path + ".lock"
+	deadline := time.Now().Add(membershipLockWait)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleMembershipLock {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// WATCH
+func (f *FileMembershipStore) Watch(ctx context.Context, onChange func(state *ClusterState, revision int64)) {
+	last, _ := os.ReadFile(f.path)
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		data, err := os.ReadFile(f.path)
+		if err != nil || bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+		state, err := decodeMembership(data)
+		if err != nil {
+			log.Println("Ignoring unreadable membership file:", err)
+			continue
+		}
+		onChange(state, fileRevision(data))
+	}
+}
+
+// EtcdMembershipStore keeps membership under a single etcd key and watches it for changes.
+type EtcdMembershipStore struct {
+	client *clientv3.Client
+	key    string
+}
+
+const etcdMembershipKey = "/tritontube/membership"
+
+// Constructor
+func NewEtcdMembershipStore(endpoints []string) (*EtcdMembershipStore, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdMembershipStore{client: client, key: etcdMembershipKey}, nil
+}
+
+// LOAD
+func (e *EtcdMembershipStore) Load() (*ClusterState, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := e.client.Get(ctx, e.key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, 0, nil
+	}
+	state, err := decodeMembership(resp.Kvs[0].Value)
+	if err != nil {
+		return nil, 0, err
+	}
+	return state, resp.Kvs[0].ModRevision, nil
+}
+
+// SAVE
+//
+// The key's mod revision is the revision; a key that doesn't exist has 0.
+func (e *EtcdMembershipStore) Save(state *ClusterState, revision int64) (int64, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := e.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(e.key), "=", revision)).
+		Then(clientv3.OpPut(e.key, string(data))).
+		Commit()
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Succeeded {
+		return 0, ErrMembershipConflict
+	}
+	return resp.Header.Revision, nil
+}
+
+// WATCH
+func (e *EtcdMembershipStore) Watch(ctx context.Context, onChange func(state *ClusterState, revision int64)) {
+	for resp := range e.client.Watch(ctx, e.key) {
+		if err := resp.Err(); err != nil {
+			log.Println("Membership watch error:", err)
+			continue
+		}
+		for _, ev := range resp.Events {
+			if ev.Type != clientv3.EventTypePut {
+				continue
+			}
+			state, err := decodeMembership(ev.Kv.Value)
+			if err != nil {
+				log.Println("Ignoring unreadable membership value:", err)
+				continue
+			}
+			onChange(state, ev.Kv.ModRevision)
+		}
+	}
+}
+
+// Close releases the etcd client.
+func (e *EtcdMembershipStore) Close() error {
+	return e.client.Close()
+}
+
+// decodeMembership also accepts the bare node list written by earlier versions.
+func decodeMembership(data []byte) (*ClusterState, error) {
+	var nodes []string
+	if err := json.Unmarshal(data, &nodes); err == nil {
+		return &ClusterState{Nodes: nodes}, nil
+	}
+	var state ClusterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+var _ MembershipStore = (*FileMembershipStore)(nil)
+var _ MembershipStore = (*EtcdMembershipStore)(nil)
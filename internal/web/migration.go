@@ -0,0 +1,232 @@
+// File migration between storage nodes, used when the ring changes
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultMigrationConcurrency is how many files are copied at once when the request doesn't say.
+const defaultMigrationConcurrency = 4
+
+// fileMove is one file that is not on the node the ring assigns it to.
+type fileMove struct {
+	videoId  string
+	filename string
+	size     int64
+	from     string
+	to       string
+}
+
+// planMigration lists every node in sources and returns the files that ring places elsewhere.
+func planMigration(ctx context.Context, ring []string, sources map[string]*storageNode) ([]fileMove, error) {
+	var moves []fileMove
+	for _, addr := range slices.Sorted(maps.Keys(sources)) {
+		resp, err := sources[addr].client.List(ctx, &proto.ListRequest{})
+		if err != nil {
+			return nil, status.Errorf(status.Code(err), "listing %s: %v", addr, err)
+		}
+		for _, file := range resp.Files {
+			owner := ringOwner(ring, file.VideoId, file.Filename)
+			if owner == addr {
+				continue
+			}
+			moves = append(moves, fileMove{
+				videoId:  file.VideoId,
+				filename: file.Filename,
+				size:     file.Size,
+				from:     addr,
+				to:       owner,
+			})
+		}
+	}
+	return moves, nil
+}
+
+// byteLimiter paces copies so that, across all workers, no more than
+// bytesPerSecond are sent on average. A zero rate means unlimited.
+type byteLimiter struct {
+	mu             sync.Mutex
+	bytesPerSecond int64
+	next           time.Time
+}
+
+// wait blocks until n more bytes may be sent.
+func (l *byteLimiter) wait(ctx context.Context, n int64) error {
+	if l.bytesPerSecond <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(time.Duration(float64(n) / float64(l.bytesPerSecond) * float64(time.Second)))
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// moveFile copies one file to its destination, checks that the destination holds
+// exactly what was read, and only then deletes it from the source.
+func moveFile(ctx context.Context, move fileMove, clients map[string]*storageNode, limiter *byteLimiter) error {
+	source, dest := clients[move.from], clients[move.to]
+	read, err := source.client.Read(ctx, &proto.ReadRequest{VideoId: move.videoId, Filename: move.filename})
+	if status.Code(err) == codes.NotFound {
+		return nil // already moved by someone else
+	} else if err != nil {
+		return err
+	}
+
+	if err := limiter.wait(ctx, int64(len(read.Data))); err != nil {
+		return err
+	}
+	_, err = dest.client.Write(ctx, &proto.WriteRequest{VideoId: move.videoId, Filename: move.filename, Data: read.Data})
+	if err != nil {
+		return err
+	}
+
+	stat, err := dest.client.Stat(ctx, &proto.StatRequest{VideoId: move.videoId, Filename: move.filename})
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(read.Data)
+	if stat.Size != int64(len(read.Data)) || !bytes.Equal(stat.Sha256, sum[:]) {
+		return status.Errorf(codes.DataLoss, "%s/%s on %s does not match the copy from %s", move.videoId, move.filename, move.to, move.from)
+	}
+
+	_, err = source.client.Delete(ctx, &proto.DeleteRequest{VideoId: move.videoId, Filename: move.filename})
+	return err
+}
+
+// runMigration moves files with a pool of workers, calling report (if not nil)
+// after every file. It stops at the first error; files not yet moved stay where
+// they are, so running the same migration again picks up where this one stopped.
+func runMigration(ctx context.Context, moves []fileMove, clients map[string]*storageNode, options *proto.MigrationOptions, report func(*proto.MigrationProgress) error) (int, error) {
+	concurrency := int(options.GetConcurrency())
+	if concurrency <= 0 {
+		concurrency = defaultMigrationConcurrency
+	}
+	limiter := &byteLimiter{bytesPerSecond: options.GetMaxBytesPerSecond()}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		progress = &proto.MigrationProgress{TotalFileCount: int32(len(moves)), TotalBytes: totalBytes(moves)}
+		start    = time.Now()
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	work := make(chan fileMove)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for move := range work {
+				if err := moveFile(ctx, move, clients, limiter); err != nil {
+					fail(err)
+					return
+				}
+
+				// report is a stream send, which must not be called concurrently
+				mu.Lock()
+				progress.MigratedFileCount++
+				progress.MigratedBytes += move.size
+				progress.CurrentVideoId = move.videoId
+				progress.CurrentFilename = move.filename
+				progress.SourceNode = move.from
+				progress.DestinationNode = move.to
+				if progress.MigratedBytes > 0 {
+					remaining := progress.TotalBytes - progress.MigratedBytes
+					progress.EtaSeconds = int64(time.Since(start).Seconds() * float64(remaining) / float64(progress.MigratedBytes))
+				}
+				var err error
+				if report != nil {
+					err = report(progress)
+				}
+				mu.Unlock()
+				if err != nil {
+					fail(err)
+					return
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, move := range moves {
+		select {
+		case work <- move:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return int(progress.MigratedFileCount), firstErr
+}
+
+// reportPlan reports each file of a dry run followed by the totals.
+func reportPlan(moves []fileMove, report func(*proto.MigrationProgress) error) error {
+	for _, move := range moves {
+		err := report(&proto.MigrationProgress{
+			TotalFileCount:  int32(len(moves)),
+			TotalBytes:      totalBytes(moves),
+			CurrentVideoId:  move.videoId,
+			CurrentFilename: move.filename,
+			SourceNode:      move.from,
+			DestinationNode: move.to,
+			DryRun:          true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return report(&proto.MigrationProgress{
+		TotalFileCount: int32(len(moves)),
+		TotalBytes:     totalBytes(moves),
+		DryRun:         true,
+		Done:           true,
+	})
+}
+
+func totalBytes(moves []fileMove) int64 {
+	var total int64
+	for _, move := range moves {
+		total += move.size
+	}
+	return total
+}
@@ -1,9 +1,778 @@
-// Lab 8: Implement a network video content service (client using consistent hashing)
-
-package web
-
-// NetworkVideoContentService implements VideoContentService using a network of nodes.
-type NetworkVideoContentService struct{}
-
-// Uncomment the following line to ensure NetworkVideoContentService implements VideoContentService
-// var _ VideoContentService = (*NetworkVideoContentService)(nil)
+// Lab 8: Implement a network video content service (client using consistent hashing)
+
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"log"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"tritontube/internal/proto"
+	"tritontube/internal/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// storageTimeout bounds a single Read/Write against a storage node.
+const storageTimeout = 30 * time.Second
+
+const (
+	migrationAdd     = "add"
+	migrationRemove  = "remove"
+	migrationDrain   = "drain"
+	migrationUndrain = "undrain"
+)
+
+// NetworkVideoContentService implements VideoContentService using a network of nodes.
+// Files are placed on nodes by consistent hashing of "<videoId>/<filename>". It also
+// serves VideoContentAdminService so nodes can be added, drained and removed at runtime.
+type NetworkVideoContentService struct {
+	proto.UnimplementedVideoContentAdminServiceServer
+
+	mu        sync.RWMutex
+	nodes     []string                // all members, sorted by position on the ring
+	draining  []string                // members that take no new writes
+	ring      []string                // nodes minus draining; decides where files belong
+	clients   map[string]*storageNode // members plus the node an unfinished removal is emptying
+	migration *Migration
+	revision  int64 // of the stored membership the fields above were loaded from or saved as
+
+	// adminMu serializes membership changes made through this server.
+	adminMu sync.Mutex
+	store   MembershipStore
+	cancel  context.CancelFunc
+
+	dialOpts []grpc.DialOption
+}
+
+type storageNode struct {
+	conn   *grpc.ClientConn
+	client proto.VideoContentStorageServiceClient
+}
+
+func hashStringToUint64(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// dial connects to a storage node with the service's dial options.
+func (n *NetworkVideoContentService) dial(addr string) (*storageNode, error) {
+	opts := append(slices.Clone(n.dialOpts), grpc.WithDefaultCallOptions(
+		grpc.MaxCallRecvMsgSize(storage.MaxMessageSize),
+		grpc.MaxCallSendMsgSize(storage.MaxMessageSize),
+	))
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &storageNode{conn: conn, client: proto.NewVideoContentStorageServiceClient(conn)}, nil
+}
+
+// Constructor
+//
+// The membership in store takes precedence over nodes, which only seed an empty store.
+// The service keeps watching store so changes made by other web servers are applied here.
+// dialOpts set up security for the storage node connections; without them they are plaintext.
+func NewNetworkVideoContentService(nodes []string, store MembershipStore, dialOpts ...grpc.DialOption) (*NetworkVideoContentService, error) {
+	state, revision, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || len(state.Nodes) == 0 {
+		seed := &ClusterState{Nodes: nodes}
+		saved, err := store.Save(seed, revision)
+		if errors.Is(err, ErrMembershipConflict) {
+			// another web server seeded it first
+			state, revision, err = store.Load()
+		} else {
+			state, revision = seed, saved
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		log.Println("Using stored cluster membership:", state.Nodes)
+	}
+	if state.Migration != nil {
+		log.Printf("Migration to %s node %s is unfinished; re-issue it to resume\n", state.Migration.Action, state.Migration.Node)
+	}
+
+	if len(dialOpts) == 0 {
+		dialOpts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	n := &NetworkVideoContentService{
+		clients:  make(map[string]*storageNode),
+		store:    store,
+		dialOpts: dialOpts,
+	}
+	for _, addr := range stateNodes(state) {
+		node, err := n.dial(addr)
+		if err != nil {
+			n.Close()
+			return nil, err
+		}
+		n.clients[addr] = node
+	}
+	n.setState(state)
+	n.revision = revision
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancel = cancel
+	go store.Watch(ctx, n.applyMembership)
+	return n, nil
+}
+
+// Close stops watching membership and closes all node connections.
+func (n *NetworkVideoContentService) Close() {
+	if n.cancel != nil {
+		n.cancel()
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, node := range n.clients {
+		node.conn.Close()
+	}
+}
+
+// stateNodes returns every node a web server needs a connection to for state.
+func stateNodes(state *ClusterState) []string {
+	nodes := slices.Clone(state.Nodes)
+	if state.Migration != nil && state.Migration.Action == migrationRemove {
+		nodes = append(nodes, state.Migration.Node)
+	}
+	return nodes
+}
+
+// state returns the current membership. Callers must hold mu.
+func (n *NetworkVideoContentService) state() *ClusterState {
+	return &ClusterState{Nodes: slices.Clone(n.nodes), Draining: slices.Clone(n.draining), Migration: n.migration}
+}
+
+// setState replaces the membership and rebuilds the ring. Callers must hold mu.
+func (n *NetworkVideoContentService) setState(state *ClusterState) {
+	n.nodes = sortRing(state.Nodes)
+	n.draining = sortedCopy(state.Draining)
+	n.ring = placementRing(state)
+	n.migration = state.Migration
+}
+
+// placementRing returns the sorted ring that decides where files of state belong.
+func placementRing(state *ClusterState) []string {
+	return sortRing(slices.DeleteFunc(slices.Clone(state.Nodes), func(addr string) bool {
+		return slices.Contains(state.Draining, addr)
+	}))
+}
+
+func sortRing(nodes []string) []string {
+	ring := slices.Clone(nodes)
+	sort.Slice(ring, func(i, j int) bool {
+		return hashStringToUint64(ring[i]) < hashStringToUint64(ring[j])
+	})
+	return ring
+}
+
+// ringOwner returns the node of ring responsible for a file. ring must be sorted.
+func ringOwner(ring []string, videoId string, filename string) string {
+	key := hashStringToUint64(videoId + "/" + filename)
+	i := sort.Search(len(ring), func(i int) bool {
+		return hashStringToUint64(ring[i]) >= key
+	})
+	if i == len(ring) {
+		i = 0
+	}
+	return ring[i]
+}
+
+// ownerOf returns the node responsible for a file. Callers must hold mu.
+func (n *NetworkVideoContentService) ownerOf(videoId string, filename string) string {
+	return ringOwner(n.ring, videoId, filename)
+}
+
+// applyMembership brings the ring in line with a membership change made elsewhere.
+// No files are moved; the server that made the change migrates them.
+func (n *NetworkVideoContentService) applyMembership(state *ClusterState, revision int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// changes can be seen late, e.g. this server's own saves coming back from a watch
+	if revision < n.revision {
+		return
+	}
+	n.revision = revision
+	if slices.Equal(sortedCopy(state.Nodes), sortedCopy(n.nodes)) &&
+		slices.Equal(sortedCopy(state.Draining), n.draining) &&
+		equalMigration(state.Migration, n.migration) {
+		return
+	}
+	log.Println("Cluster membership changed:", state.Nodes)
+
+	wanted := make(map[string]bool)
+	for _, addr := range stateNodes(state) {
+		wanted[addr] = true
+		if _, ok := n.clients[addr]; ok {
+			continue
+		}
+		node, err := n.dial(addr)
+		if err != nil {
+			log.Println("Failed to connect to node", addr, err)
+			continue
+		}
+		n.clients[addr] = node
+	}
+	for addr, node := range n.clients {
+		if !wanted[addr] {
+			node.conn.Close()
+			delete(n.clients, addr)
+		}
+	}
+
+	var live []string
+	for _, addr := range state.Nodes {
+		if _, ok := n.clients[addr]; ok {
+			live = append(live, addr)
+		}
+	}
+	n.setState(&ClusterState{Nodes: live, Draining: state.Draining, Migration: state.Migration})
+}
+
+func sortedCopy(s []string) []string {
+	c := slices.Clone(s)
+	slices.Sort(c)
+	return c
+}
+
+func equalMigration(a, b *Migration) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// WRITE
+func (n *NetworkVideoContentService) Write(videoId string, filename string, data []byte) error {
+	n.mu.RLock()
+	if len(n.ring) == 0 {
+		n.mu.RUnlock()
+		return status.Error(codes.Unavailable, "no writable storage nodes in cluster")
+	}
+	node := n.clients[n.ownerOf(videoId, filename)]
+	n.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+
+	_, err := node.client.Write(ctx, &proto.WriteRequest{VideoId: videoId, Filename: filename, Data: data})
+	return err
+}
+
+// READ
+//
+// A file that is not on its owner may still be on its previous node while a
+// migration or drain is in flight, so the other nodes are tried before giving up.
+func (n *NetworkVideoContentService) Read(videoId string, filename string) ([]byte, error) {
+	n.mu.RLock()
+	if len(n.ring) == 0 {
+		n.mu.RUnlock()
+		return nil, status.Error(codes.Unavailable, "no storage nodes in cluster")
+	}
+	owner := n.ownerOf(videoId, filename)
+	candidates := []*storageNode{n.clients[owner]}
+	for addr, node := range n.clients {
+		if addr != owner {
+			candidates = append(candidates, node)
+		}
+	}
+	n.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+
+	var firstErr error
+	for _, node := range candidates {
+		resp, err := node.client.Read(ctx, &proto.ReadRequest{VideoId: videoId, Filename: filename})
+		if err == nil {
+			return resp.Data, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if status.Code(err) != codes.NotFound {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+// DELETE
+//
+// Files may sit on any node (see Read), so every node is asked for its copy.
+func (n *NetworkVideoContentService) Delete(videoId string) error {
+	n.mu.RLock()
+	nodes := slices.Collect(maps.Values(n.clients))
+	n.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+
+	for _, node := range nodes {
+		resp, err := node.client.List(ctx, &proto.ListRequest{VideoId: videoId})
+		if err != nil {
+			return err
+		}
+		for _, file := range resp.Files {
+			_, err := node.client.Delete(ctx, &proto.DeleteRequest{VideoId: file.VideoId, Filename: file.Filename})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DELETE FILE
+func (n *NetworkVideoContentService) DeleteFile(videoId string, filename string) error {
+	n.mu.RLock()
+	nodes := slices.Collect(maps.Values(n.clients))
+	n.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+
+	for _, node := range nodes {
+		_, err := node.client.Delete(ctx, &proto.DeleteRequest{VideoId: videoId, Filename: filename})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LIST FILES
+func (n *NetworkVideoContentService) ListFiles(videoId string) ([]ContentFile, error) {
+	return n.listFiles(videoId)
+}
+
+// LIST ALL
+func (n *NetworkVideoContentService) ListAll() ([]ContentFile, error) {
+	files, err := n.listFiles("")
+	if err != nil {
+		return nil, err
+	}
+	// the blob store of a DedupVideoContentService on top lists its own files
+	return slices.DeleteFunc(files, func(file ContentFile) bool { return strings.HasPrefix(file.VideoId, ".") }), nil
+}
+
+// listFiles lists the files of videoId, or of every video when it is "", on
+// every node. A file may be on more than one node mid-migration; it is listed
+// once, with the latest modification time.
+func (n *NetworkVideoContentService) listFiles(videoId string) ([]ContentFile, error) {
+	n.mu.RLock()
+	nodes := slices.Collect(maps.Values(n.clients))
+	n.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+
+	type key struct{ videoId, filename string }
+	index := make(map[key]int)
+	var files []ContentFile
+	for _, node := range nodes {
+		resp, err := node.client.List(ctx, &proto.ListRequest{VideoId: videoId})
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range resp.Files {
+			listed := ContentFile{
+				VideoId:    file.VideoId,
+				Filename:   file.Filename,
+				Size:       file.Size,
+				ModifiedAt: file.ModifiedAt.AsTime(),
+			}
+			i, ok := index[key{file.VideoId, file.Filename}]
+			if !ok {
+				index[key{file.VideoId, file.Filename}] = len(files)
+				files = append(files, listed)
+			} else if listed.ModifiedAt.After(files[i].ModifiedAt) {
+				files[i] = listed
+			}
+		}
+	}
+	return files, nil
+}
+
+// targetState checks that a membership change can be made and returns the state it leads to.
+// A change that matches the unfinished migration is a resume and keeps the current state.
+// Callers must hold mu.
+func (n *NetworkVideoContentService) targetState(action string, addr string) (*ClusterState, error) {
+	if n.migration != nil {
+		if n.migration.Action == action && n.migration.Node == addr {
+			return n.state(), nil
+		}
+		// the only other change allowed is undoing the unfinished one
+		if n.migration.Node != addr {
+			return nil, status.Errorf(codes.FailedPrecondition, "migration to %s node %s is unfinished; resume it first", n.migration.Action, n.migration.Node)
+		}
+	}
+
+	target := n.state()
+	target.Migration = &Migration{Action: action, Node: addr}
+	isMember := slices.Contains(n.nodes, addr)
+	isDraining := slices.Contains(n.draining, addr)
+	without := func(nodes []string) []string {
+		return slices.DeleteFunc(slices.Clone(nodes), func(a string) bool { return a == addr })
+	}
+
+	switch action {
+	case migrationAdd:
+		if isMember {
+			return nil, status.Errorf(codes.AlreadyExists, "node %s is already in the cluster", addr)
+		}
+		target.Nodes = append(target.Nodes, addr)
+	case migrationRemove:
+		if !isMember {
+			return nil, status.Errorf(codes.NotFound, "node %s is not in the cluster", addr)
+		}
+		target.Nodes = without(target.Nodes)
+		target.Draining = without(target.Draining)
+	case migrationDrain:
+		if !isMember {
+			return nil, status.Errorf(codes.NotFound, "node %s is not in the cluster", addr)
+		}
+		if isDraining {
+			return nil, status.Errorf(codes.AlreadyExists, "node %s is already drained", addr)
+		}
+		target.Draining = append(target.Draining, addr)
+	case migrationUndrain:
+		if !isDraining {
+			return nil, status.Errorf(codes.FailedPrecondition, "node %s is not draining", addr)
+		}
+		target.Draining = without(target.Draining)
+	}
+	if len(placementRing(target)) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot %s %s: no writable node would be left in the cluster", action, addr)
+	}
+	return target, nil
+}
+
+// membershipSaveAttempts bounds how often a membership change is retried when
+// other web servers keep saving theirs first.
+const membershipSaveAttempts = 5
+
+// updateState saves the membership that change returns and switches to it.
+// change is called with mu held and returns nil when there is nothing to save.
+// When another web server saved membership in between, the stored membership
+// is applied and change is tried again on it.
+func (n *NetworkVideoContentService) updateState(change func() (*ClusterState, error)) error {
+	for attempt := 1; ; attempt++ {
+		n.mu.Lock()
+		target, err := change()
+		if err != nil || target == nil {
+			n.mu.Unlock()
+			return err
+		}
+		revision, err := n.store.Save(target, n.revision)
+		if err == nil {
+			n.setState(target)
+			n.revision = revision
+		}
+		n.mu.Unlock()
+		if !errors.Is(err, ErrMembershipConflict) || attempt == membershipSaveAttempts {
+			return err
+		}
+
+		state, revision, err := n.store.Load()
+		if err != nil {
+			return err
+		}
+		if state != nil {
+			n.applyMembership(state, revision)
+		}
+	}
+}
+
+// beginMigration switches the ring over and records the change as unfinished.
+// It is persisted before any file moves so other web servers route to the new ring right away.
+func (n *NetworkVideoContentService) beginMigration(action string, addr string) error {
+	return n.updateState(func() (*ClusterState, error) {
+		target, err := n.targetState(action, addr)
+		if err != nil {
+			return nil, err
+		}
+		if n.migration != nil && n.migration.Action == action {
+			return nil, nil // resuming
+		}
+		if _, ok := n.clients[addr]; !ok {
+			node, err := n.dial(addr)
+			if err != nil {
+				return nil, err
+			}
+			// make sure the node is up before any writes are routed to it
+			ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+			defer cancel()
+			if _, err := node.client.List(ctx, &proto.ListRequest{}); err != nil {
+				node.conn.Close()
+				return nil, status.Errorf(status.Code(err), "node %s is not reachable: %v", addr, err)
+			}
+			n.clients[addr] = node
+		}
+		return target, nil
+	})
+}
+
+// finishMigration clears the unfinished migration, if it is still the given
+// one, and lets go of a removed node.
+func (n *NetworkVideoContentService) finishMigration(migration *Migration) error {
+	if migration == nil {
+		return nil
+	}
+	err := n.updateState(func() (*ClusterState, error) {
+		if !equalMigration(n.migration, migration) {
+			return nil, nil // already finished, e.g. by another web server
+		}
+		target := n.state()
+		target.Migration = nil
+		return target, nil
+	})
+	if err != nil || migration.Action != migrationRemove {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if node, ok := n.clients[migration.Node]; ok && !slices.Contains(n.nodes, migration.Node) {
+		node.conn.Close()
+		delete(n.clients, migration.Node)
+	}
+	return nil
+}
+
+// migrateNode applies a membership change and moves the files affected, reporting progress if report is set.
+// With dryRun the files that would move are reported and nothing changes.
+func (n *NetworkVideoContentService) migrateNode(ctx context.Context, action string, addr string, dryRun bool, options *proto.MigrationOptions, report func(*proto.MigrationProgress) error) (int, error) {
+	n.adminMu.Lock()
+	defer n.adminMu.Unlock()
+
+	if dryRun {
+		n.mu.RLock()
+		target, err := n.targetState(action, addr)
+		n.mu.RUnlock()
+		if err != nil {
+			return 0, err
+		}
+		return n.reportMisplaced(ctx, placementRing(target), report)
+	}
+
+	if err := n.beginMigration(action, addr); err != nil {
+		return 0, err
+	}
+	return n.moveMisplaced(ctx, options, report)
+}
+
+// reportMisplaced reports the files that ring would move, without moving them.
+func (n *NetworkVideoContentService) reportMisplaced(ctx context.Context, ring []string, report func(*proto.MigrationProgress) error) (int, error) {
+	n.mu.RLock()
+	sources := maps.Clone(n.clients)
+	n.mu.RUnlock()
+
+	moves, err := planMigration(ctx, ring, sources)
+	if err != nil {
+		return 0, err
+	}
+	if report != nil {
+		err = reportPlan(moves, report)
+	}
+	return len(moves), err
+}
+
+// moveMisplaced moves every file that is not where the current ring puts it and,
+// once all of them are in place, completes the unfinished migration if there is one.
+// Callers must hold adminMu.
+func (n *NetworkVideoContentService) moveMisplaced(ctx context.Context, options *proto.MigrationOptions, report func(*proto.MigrationProgress) error) (int, error) {
+	n.mu.RLock()
+	ring := slices.Clone(n.ring)
+	clients := maps.Clone(n.clients)
+	migration := n.migration
+	n.mu.RUnlock()
+
+	moves, err := planMigration(ctx, ring, clients)
+	if err != nil {
+		return 0, err
+	}
+	migrated, err := runMigration(ctx, moves, clients, options, report)
+	if err != nil {
+		return migrated, err
+	}
+	if err := n.finishMigration(migration); err != nil {
+		return migrated, err
+	}
+	if report != nil {
+		err = report(&proto.MigrationProgress{
+			MigratedFileCount: int32(migrated),
+			TotalFileCount:    int32(len(moves)),
+			MigratedBytes:     totalBytes(moves),
+			TotalBytes:        totalBytes(moves),
+			Done:              true,
+		})
+	}
+	return migrated, err
+}
+
+// AddNode adds a storage node to the ring and moves over the files it now owns.
+func (n *NetworkVideoContentService) AddNode(ctx context.Context, req *proto.AddNodeRequest) (*proto.AddNodeResponse, error) {
+	migrated, err := n.migrateNode(ctx, migrationAdd, req.NodeAddress, false, req.Options, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.AddNodeResponse{MigratedFileCount: int32(migrated)}, nil
+}
+
+// RemoveNode takes a storage node out of the ring and moves its files to their new owners.
+func (n *NetworkVideoContentService) RemoveNode(ctx context.Context, req *proto.RemoveNodeRequest) (*proto.RemoveNodeResponse, error) {
+	migrated, err := n.migrateNode(ctx, migrationRemove, req.NodeAddress, false, req.Options, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.RemoveNodeResponse{MigratedFileCount: int32(migrated)}, nil
+}
+
+// DrainNode stops new writes to a node and moves its files to the rest of the ring.
+// The node keeps serving reads, and once drained it can be removed without moving anything.
+func (n *NetworkVideoContentService) DrainNode(ctx context.Context, req *proto.DrainNodeRequest) (*proto.DrainNodeResponse, error) {
+	migrated, err := n.migrateNode(ctx, migrationDrain, req.NodeAddress, false, req.Options, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.DrainNodeResponse{MigratedFileCount: int32(migrated)}, nil
+}
+
+// UndrainNode puts a drained node back on the ring and moves back the files it owns.
+func (n *NetworkVideoContentService) UndrainNode(ctx context.Context, req *proto.UndrainNodeRequest) (*proto.UndrainNodeResponse, error) {
+	migrated, err := n.migrateNode(ctx, migrationUndrain, req.NodeAddress, false, req.Options, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.UndrainNodeResponse{MigratedFileCount: int32(migrated)}, nil
+}
+
+// MigrateNode is AddNode/RemoveNode/DrainNode/UndrainNode with progress streamed back to the caller.
+func (n *NetworkVideoContentService) MigrateNode(req *proto.MigrateNodeRequest, stream grpc.ServerStreamingServer[proto.MigrationProgress]) error {
+	var action string
+	switch req.Action {
+	case proto.MigrateNodeRequest_ADD:
+		action = migrationAdd
+	case proto.MigrateNodeRequest_REMOVE:
+		action = migrationRemove
+	case proto.MigrateNodeRequest_DRAIN:
+		action = migrationDrain
+	case proto.MigrateNodeRequest_UNDRAIN:
+		action = migrationUndrain
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown action %v", req.Action)
+	}
+	_, err := n.migrateNode(stream.Context(), action, req.NodeAddress, req.DryRun, req.Options, stream.Send)
+	return err
+}
+
+// Rebalance moves files that are on the wrong node according to the current ring.
+func (n *NetworkVideoContentService) Rebalance(req *proto.RebalanceRequest, stream grpc.ServerStreamingServer[proto.MigrationProgress]) error {
+	n.adminMu.Lock()
+	defer n.adminMu.Unlock()
+
+	if req.DryRun {
+		n.mu.RLock()
+		ring := slices.Clone(n.ring)
+		n.mu.RUnlock()
+		_, err := n.reportMisplaced(stream.Context(), ring, stream.Send)
+		return err
+	}
+	_, err := n.moveMisplaced(stream.Context(), req.Options, stream.Send)
+	return err
+}
+
+// ClusterStatus reports what each node holds, how much of the keyspace it owns and whether it answers.
+func (n *NetworkVideoContentService) ClusterStatus(ctx context.Context, req *proto.ClusterStatusRequest) (*proto.ClusterStatusResponse, error) {
+	n.mu.RLock()
+	state := n.state()
+	ring := slices.Clone(n.ring)
+	clients := maps.Clone(n.clients)
+	n.mu.RUnlock()
+
+	resp := &proto.ClusterStatusResponse{}
+	if state.Migration != nil {
+		resp.UnfinishedMigration = state.Migration.Action + " " + state.Migration.Node
+	}
+
+	shares := keyspaceShares(ring)
+	for _, addr := range stateNodes(state) {
+		nodeStatus := &proto.NodeStatus{
+			Address:       addr,
+			Draining:      slices.Contains(state.Draining, addr),
+			Removing:      !slices.Contains(state.Nodes, addr),
+			KeyspaceShare: shares[addr],
+		}
+		resp.Nodes = append(resp.Nodes, nodeStatus)
+
+		// applyMembership leaves out nodes it couldn't dial
+		node, ok := clients[addr]
+		if !ok {
+			nodeStatus.Error = "not connected"
+			continue
+		}
+		listCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		list, err := node.client.List(listCtx, &proto.ListRequest{})
+		cancel()
+		if err != nil {
+			nodeStatus.Error = err.Error()
+			continue
+		}
+		nodeStatus.Healthy = true
+		for _, file := range list.Files {
+			nodeStatus.FileCount++
+			nodeStatus.TotalBytes += file.Size
+			if ringOwner(ring, file.VideoId, file.Filename) != addr {
+				nodeStatus.MisplacedFileCount++
+			}
+		}
+	}
+	return resp, nil
+}
+
+// keyspaceShares returns the fraction of the hash space each node of a sorted ring owns.
+// A node owns the keys after its predecessor's hash up to and including its own.
+func keyspaceShares(ring []string) map[string]float64 {
+	shares := make(map[string]float64)
+	if len(ring) == 1 {
+		shares[ring[0]] = 1
+		return shares
+	}
+	for i, addr := range ring {
+		prev := ring[(i+len(ring)-1)%len(ring)]
+		// unsigned subtraction wraps around for the first node
+		span := hashStringToUint64(addr) - hashStringToUint64(prev)
+		shares[addr] = float64(span) / math.Pow(2, 64)
+	}
+	return shares
+}
+
+// ListNodes returns the nodes in ring order.
+func (n *NetworkVideoContentService) ListNodes(ctx context.Context, req *proto.ListNodesRequest) (*proto.ListNodesResponse, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return &proto.ListNodesResponse{Nodes: slices.Clone(n.nodes), DrainingNodes: slices.Clone(n.draining)}, nil
+}
+
+// Uncomment the following line to ensure NetworkVideoContentService implements VideoContentService
+var _ VideoContentService = (*NetworkVideoContentService)(nil)
+var _ ContentLister = (*NetworkVideoContentService)(nil)
+var _ proto.VideoContentAdminServiceServer = (*NetworkVideoContentService)(nil)
@@ -0,0 +1,182 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type openAPIDocument struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+			Required   []string                   `json:"required"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadOpenAPISpec(t *testing.T) *openAPIDocument {
+	t.Helper()
+	var doc openAPIDocument
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return &doc
+}
+
+// Every registered API route must be documented, and every documented operation must be registered.
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	doc := loadOpenAPISpec(t)
+
+	var documented []string
+	for path, item := range doc.Paths {
+		for key := range item {
+			// path items also hold shared keys such as "parameters"
+			method := strings.ToUpper(key)
+			switch method {
+			case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead, http.MethodOptions:
+				documented = append(documented, method+" "+path)
+			}
+		}
+	}
+	var registered []string
+	for _, route := range apiRoutes {
+		registered = append(registered, route.method+" "+route.pattern)
+	}
+
+	for _, route := range registered {
+		if !slices.Contains(documented, route) {
+			t.Errorf("route %q is registered but missing from openapi.json", route)
+		}
+	}
+	for _, route := range documented {
+		if !slices.Contains(registered, route) {
+			t.Errorf("openapi.json documents %q but no handler is registered for it", route)
+		}
+	}
+}
+
+// The schemas must list exactly the JSON fields of the types the handlers encode and decode.
+func TestOpenAPIMatchesTypes(t *testing.T) {
+	doc := loadOpenAPISpec(t)
+
+	types := map[string]reflect.Type{
+		"Video":      reflect.TypeFor[apiVideo](),
+		"VideoList":  reflect.TypeFor[apiVideoList](),
+		"VideoPatch": reflect.TypeFor[apiVideoPatch](),
+		"Subtitle":   reflect.TypeFor[apiSubtitle](),
+		"Error":      reflect.TypeFor[apiError](),
+		"ErrorBody":  reflect.TypeFor[apiErrorBody](),
+		"Limit":      reflect.TypeFor[apiLimit](),
+		"Job":        reflect.TypeFor[apiJob](),
+		"Usage":      reflect.TypeFor[apiUsage](),
+	}
+	for name, typ := range types {
+		schema, ok := doc.Components.Schemas[name]
+		if !ok {
+			t.Errorf("openapi.json has no %s schema for %s", name, typ)
+			continue
+		}
+
+		fields := map[string]bool{} // json name -> omitempty
+		for i := range typ.NumField() {
+			field := typ.Field(i)
+			tag := field.Tag.Get("json")
+			jsonName, options, _ := strings.Cut(tag, ",")
+			if jsonName == "" || jsonName == "-" {
+				t.Errorf("%s.%s has no json name", typ, field.Name)
+				continue
+			}
+			fields[jsonName] = strings.Contains(options, "omitempty")
+		}
+
+		for jsonName := range fields {
+			if _, ok := schema.Properties[jsonName]; !ok {
+				t.Errorf("%s.%s is not in the %s schema", typ, jsonName, name)
+			}
+		}
+		for property := range schema.Properties {
+			if _, ok := fields[property]; !ok {
+				t.Errorf("the %s schema has %s, which %s does not", name, property, typ)
+			}
+		}
+		for _, required := range schema.Required {
+			if omitempty, ok := fields[required]; ok && omitempty {
+				t.Errorf("the %s schema requires %s, but %s omits it when empty", name, required, typ)
+			}
+		}
+	}
+}
+
+// A known path called with a method it doesn't take gets a JSON error, like every failed API request.
+func TestAPIMethodNotAllowed(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go NewServer(nil, nil, Config{}).Start(lis)
+
+	tests := []struct {
+		method string
+		path   string
+		allow  string
+	}{
+		{http.MethodPut, "/api/videos", "GET, HEAD, POST"},
+		{http.MethodPost, "/api/videos/a", "GET, HEAD, PATCH, DELETE"},
+		{http.MethodGet, "/api/videos/a/subtitles/en", "PUT, DELETE"},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest(test.method, "http://"+lis.Addr().String()+test.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body apiError
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed || err != nil || body.Error.Code != "method_not_allowed" {
+			t.Errorf("%s %s: %s, code %q, decode error %v; want 405 method_not_allowed", test.method, test.path, resp.Status, body.Error.Code, err)
+		}
+		if got := resp.Header.Get("Allow"); got != test.allow {
+			t.Errorf("%s %s: Allow = %q, want %q", test.method, test.path, got, test.allow)
+		}
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go NewServer(nil, nil, Config{}).Start(lis)
+
+	resp, err := http.Get("http://" + lis.Addr().String() + "/api/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/openapi.json: %s", resp.Status)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if string(body) != string(openAPISpec) {
+		t.Errorf("served document differs from openapi.json")
+	}
+}
@@ -0,0 +1,219 @@
+// Original uploads kept in a cold tier, and re-transcoding videos from them
+
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+	"tritontube/internal/proto"
+)
+
+const (
+	originalFilename           = "original"
+	compressedOriginalFilename = "original.gz"
+)
+
+// keepOriginal stores the uploaded file at path as the original of videoId, if
+// originals are kept. Content services take whole files, so the file is read
+// into memory here; when compressing, only its compressed form is.
+func (s *server) keepOriginal(videoId string, path string) error {
+	if s.originals == nil {
+		return nil
+	}
+	if !s.compressOriginals {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return s.originals.Write(videoId, originalFilename, data)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := io.Copy(zw, file); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return s.originals.Write(videoId, compressedOriginalFilename, compressed.Bytes())
+}
+
+// readOriginal returns the uploaded file of videoId. Both forms are tried, so
+// turning compression on or off doesn't strand earlier uploads.
+func (s *server) readOriginal(videoId string) ([]byte, error) {
+	if s.originals == nil {
+		return nil, &requestError{http.StatusNotImplemented, "not_implemented", "this server doesn't keep original uploads"}
+	}
+	compressed, err := s.originals.Read(videoId, compressedOriginalFilename)
+	if err == nil {
+		zr, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(zr)
+	}
+	data, err := s.originals.Read(videoId, originalFilename)
+	if err != nil {
+		// content services don't tell a missing file from a failed read
+		return nil, &requestError{http.StatusNotFound, "no_original", "no original is kept for this video: " + err.Error()}
+	}
+	return data, nil
+}
+
+// retranscode regenerates the DASH files and previews of a video from its
+// kept original, as a job of the video's uploader. They are stored as a new
+// version, which replaces the current one once complete; subtitles are left alone.
+func (s *server) retranscode(ctx context.Context, videoId string) (_ *VideoMetadata, err error) {
+	video, err := s.metadataService.Read(videoId)
+	if err != nil {
+		return nil, err
+	}
+	if video == nil {
+		return nil, &requestError{http.StatusNotFound, "not_found", "video not found"}
+	}
+	data, err := s.readOriginal(videoId)
+	if err != nil {
+		return nil, err
+	}
+
+	job, ctx, err := s.jobs.start(ctx, videoId, video.Uploader)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		s.jobs.finish(job, err)
+		if err != nil {
+			err = &jobError{job.record.Id, err}
+		}
+	}()
+
+	tempDir, err := os.MkdirTemp("", "retranscode-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+	inputPath := filepath.Join(tempDir, "input")
+	if err := os.WriteFile(inputPath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// the upload limits may have changed since, but the video is already accepted
+	probe, err := probeMedia(ctx, job, inputPath)
+	if err != nil {
+		return nil, jobStopped(ctx, err)
+	}
+	// re-transcodes aren't charged against the uploader's quota; they are not the uploader's doing
+	version, outputDir, err := s.transcode(ctx, job, videoId, inputPath, tempDir, probe)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storeVersion(videoId, outputDir); err != nil {
+		return nil, err
+	}
+
+	if err := s.switchVersion(video, version, audioLanguages(probe)); err != nil {
+		return nil, err
+	}
+	// read again for changes made while transcoding, e.g. to its visibility
+	video, err = s.metadataService.Read(videoId)
+	if err != nil {
+		return nil, err
+	}
+	if video == nil {
+		return nil, &requestError{http.StatusNotFound, "not_found", "video not found"}
+	}
+	return video, nil
+}
+
+// retranscodeProgressInterval is how often retranscodeAll reports the progress
+// of the video it is working on.
+const retranscodeProgressInterval = 2 * time.Second
+
+// retranscodeAll re-transcodes every video in turn, reporting each one as it
+// starts, while it runs and when it ends. A failed video doesn't stop the
+// others; only ending ctx or a failing report does. With dryRun the videos are
+// only reported.
+func (s *server) retranscodeAll(ctx context.Context, dryRun bool, report func(*proto.RetranscodeProgress) error) error {
+	if s.originals == nil {
+		return &requestError{http.StatusNotImplemented, "not_implemented", "this server doesn't keep original uploads"}
+	}
+	videos, err := s.metadataService.List()
+	if err != nil {
+		return err
+	}
+
+	total := int32(len(videos))
+	for i, video := range videos {
+		progress := &proto.RetranscodeProgress{VideoId: video.Id, Done: int32(i), Total: total}
+		if dryRun {
+			progress.State = proto.RetranscodeProgress_PENDING
+			progress.Done++
+			if err := report(progress); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := report(progress); err != nil {
+			return err
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := s.retranscode(ctx, video.Id)
+			done <- err
+		}()
+		ticker := time.NewTicker(retranscodeProgressInterval)
+	running:
+		for {
+			select {
+			case err = <-done:
+				break running
+			case <-ticker.C:
+				if record, ok := s.jobs.running(video.Id); ok {
+					progress.Progress = record.Progress
+					if err := report(progress); err != nil {
+						ticker.Stop()
+						// the job is bound to ctx, which the failed stream has ended
+						<-done
+						return err
+					}
+				}
+			}
+		}
+		ticker.Stop()
+
+		progress.Done++
+		progress.Progress = 0
+		var reqErr *requestError
+		switch {
+		case err == nil:
+			progress.State = proto.RetranscodeProgress_SUCCEEDED
+			progress.Progress = 100
+		case errors.As(err, &reqErr) && reqErr.code == "no_original":
+			progress.State = proto.RetranscodeProgress_SKIPPED
+			progress.Error = err.Error()
+		default:
+			progress.State = proto.RetranscodeProgress_FAILED
+			progress.Error = err.Error()
+		}
+		if err := report(progress); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+	}
+	return nil
+}
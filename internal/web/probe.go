@@ -0,0 +1,240 @@
+// Media inspection with ffprobe
+
+package web
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mediaProbe is the part of `ffprobe -show_format -show_streams` output we use.
+type mediaProbe struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+	} `json:"format"`
+	Streams []mediaStream `json:"streams"`
+}
+
+type mediaStream struct {
+	Index     int    `json:"index"`
+	CodecType string `json:"codec_type"`
+	CodecName string `json:"codec_name"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	// AvgFrameRate is a fraction such as "30000/1001"
+	AvgFrameRate string `json:"avg_frame_rate"`
+	Disposition  struct {
+		// AttachedPic is set on cover art, which is a video stream of one frame
+		AttachedPic int `json:"attached_pic"`
+	} `json:"disposition"`
+	Tags struct {
+		Language string `json:"language"`
+	} `json:"tags"`
+}
+
+// probeMedia inspects the file at path.
+func probeMedia(ctx context.Context, job *transcodeJob, path string) (*mediaProbe, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := job.command(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		path,
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffprobe: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	var probe mediaProbe
+	if err := json.Unmarshal(stdout.Bytes(), &probe); err != nil {
+		return nil, fmt.Errorf("ffprobe: %v", err)
+	}
+	return &probe, nil
+}
+
+// videoStream returns the main video stream, or nil for audio-only files.
+func (p *mediaProbe) videoStream() *mediaStream {
+	for i := range p.Streams {
+		if p.Streams[i].CodecType == "video" && p.Streams[i].Disposition.AttachedPic == 0 {
+			return &p.Streams[i]
+		}
+	}
+	return nil
+}
+
+func (p *mediaProbe) audioStreams() []mediaStream {
+	var streams []mediaStream
+	for _, stream := range p.Streams {
+		if stream.CodecType == "audio" {
+			streams = append(streams, stream)
+		}
+	}
+	return streams
+}
+
+// frameRate returns the average frame rate, or 0 when unknown.
+func (s *mediaStream) frameRate() float64 {
+	num, den, ok := strings.Cut(s.AvgFrameRate, "/")
+	if !ok {
+		return 0
+	}
+	n, err1 := strconv.ParseFloat(num, 64)
+	d, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+// language returns the stream's ISO 639 language tag, or "und" when it has none.
+func (s *mediaStream) language() string {
+	if s.Tags.Language == "" {
+		return "und"
+	}
+	return s.Tags.Language
+}
+
+// Defaults for the upload policy. Containers are ffprobe format names; ffprobe
+// reports e.g. "mov,mp4,m4a,3gp,3g2,mj2" for MP4 and MOV files, and a file is
+// accepted when any of the reported names is allowed.
+var (
+	DefaultAllowedContainers  = []string{"mov", "mp4", "matroska", "webm", "avi"}
+	DefaultAllowedVideoCodecs = []string{"h264", "hevc", "vp8", "vp9", "av1", "mpeg4", "mpeg2video", "mjpeg", "prores"}
+	DefaultAllowedAudioCodecs = []string{"aac", "mp3", "opus", "vorbis", "ac3", "eac3", "flac", "alac", "pcm_s16le", "pcm_s24le"}
+)
+
+// Default upload limits. Resolution limits apply in either orientation, so
+// portrait 2160x3840 passes the same limits as landscape 3840x2160.
+const (
+	DefaultMaxDuration    = 3 * time.Hour
+	DefaultMaxWidth       = 3840
+	DefaultMaxHeight      = 2160
+	DefaultMaxFrameRate   = 60
+	DefaultMaxUploadBytes = 2 << 30
+)
+
+// mediaPolicy decides which probed uploads are transcoded.
+type mediaPolicy struct {
+	containers  []string
+	videoCodecs []string
+	audioCodecs []string
+
+	maxDuration    time.Duration
+	maxWidth       int
+	maxHeight      int
+	maxFrameRate   float64
+	maxUploadBytes int64
+}
+
+// limitError is an upload that exceeds one of the configured limits.
+type limitError struct {
+	*requestError
+	limit   string
+	maximum float64
+	actual  float64
+	unit    string
+}
+
+func (e *limitError) Unwrap() error {
+	return e.requestError
+}
+
+// exceeded reports that actual is over the limit; an actual of 0 means it is not known.
+func exceeded(limit string, maximum float64, actual float64, unit string) *limitError {
+	message := fmt.Sprintf("%s exceeds the limit of %g %s", limit, maximum, unit)
+	if actual > 0 {
+		message = fmt.Sprintf("%s of %g %s exceeds the limit of %g %s", limit, actual, unit, maximum, unit)
+	}
+	return &limitError{
+		requestError: &requestError{
+			status:  http.StatusRequestEntityTooLarge,
+			code:    "limit_exceeded",
+			message: message,
+		},
+		limit:   limit,
+		maximum: maximum,
+		actual:  actual,
+		unit:    unit,
+	}
+}
+
+func newMediaPolicy(config Config) *mediaPolicy {
+	orDefault := func(list []string, fallback []string) []string {
+		if len(list) == 0 {
+			return fallback
+		}
+		return list
+	}
+	return &mediaPolicy{
+		containers:  orDefault(config.AllowedContainers, DefaultAllowedContainers),
+		videoCodecs: orDefault(config.AllowedVideoCodecs, DefaultAllowedVideoCodecs),
+		audioCodecs: orDefault(config.AllowedAudioCodecs, DefaultAllowedAudioCodecs),
+
+		maxDuration:    cmp.Or(config.MaxDuration, DefaultMaxDuration),
+		maxWidth:       cmp.Or(config.MaxWidth, DefaultMaxWidth),
+		maxHeight:      cmp.Or(config.MaxHeight, DefaultMaxHeight),
+		maxFrameRate:   cmp.Or(config.MaxFrameRate, DefaultMaxFrameRate),
+		maxUploadBytes: cmp.Or(config.MaxUploadBytes, DefaultMaxUploadBytes),
+	}
+}
+
+// checkSize rejects uploads larger than the size limit. size may be 0 when unknown.
+func (p *mediaPolicy) checkSize(size int64) error {
+	if size > p.maxUploadBytes {
+		return exceeded("file size", float64(p.maxUploadBytes), float64(size), "bytes")
+	}
+	return nil
+}
+
+// check explains, as a request error, why probe is not accepted.
+// Streams other than audio and video, such as subtitles, are ignored.
+func (p *mediaPolicy) check(probe *mediaProbe) error {
+	reject := func(format string, args ...any) error {
+		return &requestError{http.StatusUnsupportedMediaType, "unsupported_media", fmt.Sprintf(format, args...)}
+	}
+
+	formats := strings.Split(probe.Format.FormatName, ",")
+	if !slices.ContainsFunc(formats, func(format string) bool { return slices.Contains(p.containers, format) }) {
+		return reject("container %s is not accepted (allowed: %s)", probe.Format.FormatName, strings.Join(p.containers, ", "))
+	}
+	video := probe.videoStream()
+	if video == nil {
+		return reject("the file has no video stream")
+	}
+	if !slices.Contains(p.videoCodecs, video.CodecName) {
+		return reject("video codec %s is not accepted (allowed: %s)", video.CodecName, strings.Join(p.videoCodecs, ", "))
+	}
+	for _, audio := range probe.audioStreams() {
+		if !slices.Contains(p.audioCodecs, audio.CodecName) {
+			return reject("audio codec %s is not accepted (allowed: %s)", audio.CodecName, strings.Join(p.audioCodecs, ", "))
+		}
+	}
+
+	// ffprobe reports "N/A" or nothing when it can't tell; such values are let through
+	if duration, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil && duration > p.maxDuration.Seconds() {
+		return exceeded("duration", p.maxDuration.Seconds(), duration, "seconds")
+	}
+	long, short := max(video.Width, video.Height), min(video.Width, video.Height)
+	if long > max(p.maxWidth, p.maxHeight) {
+		return exceeded("resolution", float64(max(p.maxWidth, p.maxHeight)), float64(long), "pixels on the long side")
+	}
+	if short > min(p.maxWidth, p.maxHeight) {
+		return exceeded("resolution", float64(min(p.maxWidth, p.maxHeight)), float64(short), "pixels on the short side")
+	}
+	if frameRate := video.frameRate(); frameRate > p.maxFrameRate {
+		return exceeded("frame rate", p.maxFrameRate, math.Round(frameRate*100)/100, "frames per second")
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testProbe builds ffprobe output for a file with one video stream and the given audio codecs.
+func testProbe(t *testing.T, format string, duration string, codec string, width int, height int, frameRate string, audioCodecs ...string) *mediaProbe {
+	t.Helper()
+	streams := []map[string]any{{
+		"codec_type": "video", "codec_name": codec,
+		"width": width, "height": height, "avg_frame_rate": frameRate,
+	}}
+	for _, audio := range audioCodecs {
+		streams = append(streams, map[string]any{"codec_type": "audio", "codec_name": audio})
+	}
+	output, err := json.Marshal(map[string]any{
+		"format":  map[string]any{"format_name": format, "duration": duration},
+		"streams": streams,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var probe mediaProbe
+	if err := json.Unmarshal(output, &probe); err != nil {
+		t.Fatal(err)
+	}
+	return &probe
+}
+
+func TestMediaPolicyCheck(t *testing.T) {
+	policy := newMediaPolicy(Config{
+		MaxDuration:  10 * time.Minute,
+		MaxWidth:     1920,
+		MaxHeight:    1080,
+		MaxFrameRate: 30,
+	})
+	audioOnly := testProbe(t, "mov,mp4,m4a,3gp,3g2,mj2", "60", "mjpeg", 500, 500, "0/0", "aac")
+	// cover art is a one-frame video stream
+	audioOnly.Streams[0].Disposition.AttachedPic = 1
+
+	tests := []struct {
+		name  string
+		probe *mediaProbe
+		// error code when the file is rejected, and the limit exceeded or
+		// part of the reason for an unsupported file
+		code   string
+		reason string
+	}{
+		{name: "accepted", probe: testProbe(t, "mov,mp4,m4a,3gp,3g2,mj2", "600.0", "h264", 1920, 1080, "30000/1001", "aac")},
+		{name: "portrait", probe: testProbe(t, "mov,mp4,m4a,3gp,3g2,mj2", "60", "h264", 1080, 1920, "30/1")},
+		{name: "unknown duration and frame rate", probe: testProbe(t, "matroska,webm", "N/A", "vp9", 1280, 720, "0/0", "opus")},
+		{name: "container", probe: testProbe(t, "flv", "60", "h264", 1280, 720, "30/1"), code: "unsupported_media", reason: "container flv"},
+		{name: "video codec", probe: testProbe(t, "avi", "60", "wmv3", 1280, 720, "30/1"), code: "unsupported_media", reason: "video codec wmv3"},
+		{name: "audio codec", probe: testProbe(t, "avi", "60", "h264", 1280, 720, "30/1", "aac", "wmav2"), code: "unsupported_media", reason: "audio codec wmav2"},
+		{name: "cover art only", probe: audioOnly, code: "unsupported_media", reason: "no video stream"},
+		{name: "duration", probe: testProbe(t, "mp4", "600.5", "h264", 1280, 720, "30/1"), code: "limit_exceeded", reason: "duration"},
+		{name: "long side", probe: testProbe(t, "mp4", "60", "h264", 3840, 1080, "30/1"), code: "limit_exceeded", reason: "resolution"},
+		{name: "short side", probe: testProbe(t, "mp4", "60", "h264", 1440, 1440, "30/1"), code: "limit_exceeded", reason: "resolution"},
+		{name: "frame rate", probe: testProbe(t, "mp4", "60", "h264", 1280, 720, "60000/1001"), code: "limit_exceeded", reason: "frame rate"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := policy.check(test.probe)
+			if test.code == "" {
+				if err != nil {
+					t.Fatalf("check() = %v, want accepted", err)
+				}
+				return
+			}
+			var reqErr *requestError
+			if !errors.As(err, &reqErr) || reqErr.code != test.code {
+				t.Fatalf("check() = %v, want %s", err, test.code)
+			}
+			var limitErr *limitError
+			if errors.As(err, &limitErr) != (test.code == "limit_exceeded") {
+				t.Fatalf("check() = %#v, want a limit error: %v", err, test.code == "limit_exceeded")
+			}
+			if limitErr != nil && limitErr.limit != test.reason {
+				t.Errorf("exceeded limit = %q, want %q", limitErr.limit, test.reason)
+			}
+			if limitErr == nil && !strings.Contains(reqErr.message, test.reason) {
+				t.Errorf("check() = %q, want it to mention %q", reqErr.message, test.reason)
+			}
+		})
+	}
+}
+
+func TestMediaPolicyCheckSize(t *testing.T) {
+	policy := newMediaPolicy(Config{MaxUploadBytes: 1000})
+	tests := []struct {
+		size     int64
+		exceeded bool
+	}{
+		{0, false}, // unknown
+		{1000, false},
+		{1001, true},
+	}
+	for _, test := range tests {
+		err := policy.checkSize(test.size)
+		var limitErr *limitError
+		if errors.As(err, &limitErr) != test.exceeded {
+			t.Errorf("checkSize(%d) = %v, want exceeded: %v", test.size, err, test.exceeded)
+			continue
+		}
+		if limitErr != nil && (limitErr.maximum != 1000 || limitErr.actual != float64(test.size)) {
+			t.Errorf("checkSize(%d) reported %g of %g", test.size, limitErr.actual, limitErr.maximum)
+		}
+	}
+
+	if got := newMediaPolicy(Config{}).maxUploadBytes; got != DefaultMaxUploadBytes {
+		t.Errorf("default upload limit = %d, want %d", got, DefaultMaxUploadBytes)
+	}
+}
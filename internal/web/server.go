@@ -3,13 +3,17 @@
 package web
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -24,27 +28,151 @@ type server struct {
 
 	metadataService VideoMetadataService
 	contentService  VideoContentService
+	// users is nil when the metadata store has no account support
+	users UserService
+	// subtitles is nil when the metadata store has no subtitle support
+	subtitles SubtitleService
+	signer    *urlSigner
+	media     *mediaPolicy
+	jobs      *jobRegistry
+	// originals is nil when uploads aren't kept after transcoding
+	originals         VideoContentService
+	compressOriginals bool
+	encoding          EncodingProfile
+	versions          VersionService
+	versionGrace      time.Duration
+	gcInterval        time.Duration
+	gcGrace           time.Duration
+	// usage is nil when the metadata store doesn't record file sizes
+	usage UsageService
+	quota int64
 
 	mux *http.ServeMux
 }
 
+// Config holds optional server settings; the zero value uses defaults.
+type Config struct {
+	// URLSigningKey signs content URLs. Web servers behind the same CDN or load
+	// balancer need the same key. When empty a random key is used, so content
+	// URLs stop working when the server restarts.
+	URLSigningKey []byte
+	// ContentURLTTL is how long a signed content URL stays valid (default 6h).
+	ContentURLTTL time.Duration
+	// AllowedContainers, AllowedVideoCodecs and AllowedAudioCodecs list the
+	// ffprobe names accepted for upload; empty lists use the defaults.
+	AllowedContainers  []string
+	AllowedVideoCodecs []string
+	AllowedAudioCodecs []string
+	// Upload limits checked before transcoding; zero values use the defaults.
+	MaxDuration    time.Duration
+	MaxWidth       int
+	MaxHeight      int
+	MaxFrameRate   float64
+	MaxUploadBytes int64
+	// TranscodeTimeout bounds the ffmpeg work for one upload (default 2h).
+	TranscodeTimeout time.Duration
+	// TranscodeThreads caps the threads of each ffmpeg process; 0 lets ffmpeg decide.
+	TranscodeThreads int
+	// TranscodeNice lowers the CPU priority of ffmpeg by this much; 0 leaves it alone.
+	TranscodeNice int
+	// Originals keeps uploaded files after transcoding so videos can be
+	// re-transcoded later. It is usually a cheaper, colder tier than the DASH
+	// content; nil discards them.
+	Originals VideoContentService
+	// CompressOriginals gzips kept uploads. Video barely compresses, so this
+	// mostly pays off for uncompressed or lightly compressed sources.
+	CompressOriginals bool
+	// Encoding is used for uploads and re-transcodes.
+	Encoding EncodingProfile
+	// VersionGracePeriod is how long the files of a replaced transcode are kept
+	// for viewers who are still playing it (default ContentURLTTL).
+	VersionGracePeriod time.Duration
+	// GCInterval is how often orphaned files are collected; 0 leaves it to
+	// the admin gc command.
+	GCInterval time.Duration
+	// GCGracePeriod is how old an orphaned file must be to be deleted (default 24h).
+	GCGracePeriod time.Duration
+	// UserQuotaBytes caps the bytes stored for each user's videos, kept
+	// originals included; 0 means no cap. It needs a metadata service that
+	// implements UsageService.
+	UserQuotaBytes int64
+}
+
 func NewServer(
 	metadataService VideoMetadataService,
 	contentService VideoContentService,
+	config Config,
 ) *server {
+	users, _ := metadataService.(UserService)
+	subtitles, _ := metadataService.(SubtitleService)
+	jobs, ok := metadataService.(JobService)
+	if !ok {
+		jobs = newMemoryJobStore()
+	}
+	versions, ok := metadataService.(VersionService)
+	if !ok {
+		versions = &memoryVersionStore{}
+	}
+	signer := newURLSigner(config.URLSigningKey, config.ContentURLTTL)
+	versionGrace := config.VersionGracePeriod
+	if versionGrace <= 0 {
+		versionGrace = signer.ttl
+	}
+	gcGrace := config.GCGracePeriod
+	if gcGrace <= 0 {
+		gcGrace = DefaultGCGracePeriod
+	}
+	originals := config.Originals
+	usage, ok := metadataService.(UsageService)
+	if ok {
+		contentService = withAccounting(contentService, usage, contentStore)
+		if originals != nil {
+			originals = withAccounting(originals, usage, originalsStore)
+		}
+	} else if config.UserQuotaBytes > 0 {
+		log.Printf("The metadata service doesn't record storage usage; user quotas are not enforced")
+	}
 	return &server{
 		metadataService: metadataService,
 		contentService:  contentService,
+		users:           users,
+		subtitles:       subtitles,
+		signer:          signer,
+		media:           newMediaPolicy(config),
+		jobs:            newJobRegistry(jobs, config),
+
+		originals:         originals,
+		compressOriginals: config.CompressOriginals,
+		encoding:          config.Encoding,
+		versions:          versions,
+		versionGrace:      versionGrace,
+		gcInterval:        config.GCInterval,
+		gcGrace:           gcGrace,
+		usage:             usage,
+		quota:             config.UserQuotaBytes,
 	}
 }
 
 func (s *server) Start(lis net.Listener) error {
 	s.mux = http.NewServeMux()
 	s.mux.HandleFunc("/upload", s.handleUpload)
+	s.mux.HandleFunc("/register", s.handleRegister)
+	s.mux.HandleFunc("/login", s.handleLogin)
+	s.mux.HandleFunc("/logout", s.handleLogout)
 	s.mux.HandleFunc("/videos/", s.handleVideo)
+	s.mux.HandleFunc("POST /videos/{id}/subtitles", s.handleUploadSubtitle)
+	s.mux.HandleFunc("POST /videos/{id}/subtitles/{language}/delete", s.handleDeleteSubtitle)
+	s.mux.HandleFunc("GET /jobs/{id}", s.handleJob)
+	s.mux.HandleFunc("POST /jobs/{id}/cancel", s.handleCancelJob)
 	s.mux.HandleFunc("/content/", s.handleVideoContent)
+	s.registerAPI()
 	s.mux.HandleFunc("/", s.handleIndex)
 
+	go s.collectVersionsEvery(min(s.versionGrace, versionCollectInterval))
+	if s.gcInterval > 0 {
+		go s.collectGarbageEvery(s.gcInterval)
+	}
+	go s.backfillUsage()
 	return http.Serve(lis, s.mux)
 }
 
@@ -66,19 +194,68 @@ func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		Id         string
 		EscapedId  string
 		UploadTime string
+		Uploader   string
+		Visibility Visibility
+		PosterURL  string
+	}
+	type RecentJob struct {
+		Id        string
+		VideoId   string
+		StartedAt string
+		State     JobState
+		Progress  string
 	}
 
+	// only public videos are listed, plus the viewer's own
+	user := s.currentUser(r)
 	var escaped []EscapedVideo
 	for _, video := range videos {
+		if !isListed(&video, user) {
+			continue
+		}
 		escaped = append(escaped, EscapedVideo{
 			Id:         video.Id,
 			EscapedId:  url.PathEscape(video.Id),
 			UploadTime: video.UploadedAt.Format("2006-01-02 15:04:05"),
+			Uploader:   video.Uploader,
+			Visibility: video.Visibility,
+			PosterURL:  s.signer.contentURL(video.Id, versionedFilename(video.Version, posterFilename), time.Now()),
 		})
 	}
 
+	// uploads still transcoding, e.g. in another tab, can be followed and cancelled from here
+	var jobs []RecentJob
+	if user != "" {
+		records, err := s.jobs.list(user, recentJobs)
+		if err != nil {
+			// the videos are still worth showing without the uploads section
+			log.Printf("Listing jobs of %s: %v", user, err)
+		}
+		for _, job := range records {
+			jobs = append(jobs, RecentJob{
+				Id:        job.Id,
+				VideoId:   job.VideoId,
+				StartedAt: job.StartedAt.Format("2006-01-02 15:04:05"),
+				State:     job.State,
+				Progress:  fmt.Sprintf("%.0f%%", job.Progress),
+			})
+		}
+	}
+
+	data := struct {
+		User            string
+		AccountsEnabled bool
+		Videos          []EscapedVideo
+		Jobs            []RecentJob
+	}{
+		User:            user,
+		AccountsEnabled: s.users != nil,
+		Videos:          escaped,
+		Jobs:            jobs,
+	}
+
 	tmpl := template.Must(template.New("index").Parse(indexHTML))
-	err = tmpl.Execute(w, escaped)
+	err = tmpl.Execute(w, data)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
@@ -94,79 +271,253 @@ func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// anonymous visitors can only browse
+	uploader := s.currentUser(r)
+	if uploader == "" {
+		http.Error(w, "log in to upload videos", http.StatusUnauthorized)
+		return
+	}
+
+	video, err := s.ingestUpload(r, uploader)
+	var duplicate *duplicateError
+	var failedJob *jobError
+	if errors.As(err, &duplicate) {
+		s.renderDuplicatePage(w, duplicate.videoId)
+		return
+	} else if errors.As(err, &failedJob) {
+		// the job page has the whole ffmpeg log
+		http.Redirect(w, r, "/jobs/"+failedJob.jobId, http.StatusSeeOther)
+		return
+	} else if err != nil {
+		status, _ := errorStatus(err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	http.Redirect(w, r, "/videos/"+url.PathEscape(video.Id), http.StatusSeeOther)
+}
+
+// renderDuplicatePage links a duplicate upload to the video it duplicates.
+func (s *server) renderDuplicatePage(w http.ResponseWriter, videoId string) {
+	tmpl := template.Must(template.New("duplicate").Parse(duplicateHTML))
+	w.WriteHeader(http.StatusConflict)
+	tmpl.Execute(w, struct {
+		Id        string
+		EscapedId string
+	}{
+		Id:        videoId,
+		EscapedId: url.PathEscape(videoId),
+	})
+}
+
+// requestError is an error caused by the request rather than by the server.
+type requestError struct {
+	status int
+	// code is a short machine-readable name for the JSON API
+	code    string
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// errorStatus returns the HTTP status and API error code for err.
+func errorStatus(err error) (int, string) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return reqErr.status, reqErr.code
+	}
+	return http.StatusInternalServerError, "internal"
+}
+
+// ingestUpload ingests the multipart "file" of r. Form and API uploads both go through here.
+func (s *server) ingestUpload(r *http.Request, uploader string) (*VideoMetadata, error) {
+	// stop reading oversized uploads early; the slack is for the other form fields
+	r.Body = http.MaxBytesReader(nil, r.Body, s.media.maxUploadBytes+1<<20)
 	err := r.ParseMultipartForm(32 << 20)
 	//	32 mb
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, exceeded("file size", float64(s.media.maxUploadBytes), 0, "bytes")
+	} else if err != nil {
+		return nil, &requestError{http.StatusBadRequest, "invalid_form", err.Error()}
 	}
 
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return nil, &requestError{http.StatusBadRequest, "invalid_form", err.Error()}
 	}
 	defer file.Close()
+	if err := s.media.checkSize(header.Size); err != nil {
+		return nil, err
+	}
 
-	filename := header.Filename
-	if !strings.HasSuffix(filename, ".mp4") {
-		http.Error(w, "only .mp4 files are allowed", http.StatusBadRequest)
-		return
+	visibility, ok := ParseVisibility(r.FormValue("visibility"))
+	if !ok {
+		return nil, &requestError{http.StatusBadRequest, "invalid_visibility", "visibility must be public, unlisted or private"}
 	}
-	videoId := strings.TrimSuffix(filename, ".mp4")
 
+	// the content decides whether the file is accepted, so the extension only
+	// has to be dropped from the id
+	filename := header.Filename
+	videoId := strings.TrimSuffix(filename, filepath.Ext(filename))
+	// a client that goes away takes its transcode with it
+	return s.ingestVideo(r.Context(), videoId, uploader, visibility, file)
+}
+
+// ingestVideo transcodes source to DASH, stores the output and creates the
+// video's metadata. The work runs as a job that ends with ctx, can be
+// cancelled, and is bounded by the transcode timeout.
+func (s *server) ingestVideo(ctx context.Context, videoId string, uploader string, visibility Visibility, source io.Reader) (_ *VideoMetadata, err error) {
+	// a leading dot also keeps out ".", ".." and blobVideoId
+	if videoId == "" || strings.ContainsAny(videoId, `/\`) || strings.HasPrefix(videoId, ".") {
+		return nil, &requestError{http.StatusBadRequest, "invalid_id", "video ids can't be empty, start with a dot or contain slashes"}
+	}
 	isExisting, _ := s.metadataService.Read(videoId)
 	if isExisting != nil {
-		http.Error(w, "video already exists", http.StatusBadRequest)
-		return
+		return nil, &requestError{http.StatusConflict, "already_exists", "video already exists"}
 	}
+	job, ctx, err := s.jobs.start(ctx, videoId, uploader)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		s.jobs.finish(job, err)
+		if err != nil {
+			err = &jobError{job.record.Id, err}
+		}
+	}()
 
 	tempDir, err := os.MkdirTemp("", "upload-")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 	defer os.RemoveAll(tempDir)
 
-	inputPath := filepath.Join(tempDir, "input.mp4")
-	outputDir := filepath.Join(tempDir, "out")
-	err = os.MkdirAll(outputDir, 0755)
+	// no extension, so ffmpeg goes by the content alone
+	inputPath := filepath.Join(tempDir, "input")
+
+	//save input file, hashing it on the way so uploads are never held in memory
+	input, err := os.Create(inputPath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return nil, err
+	}
+	hasher := sha256.New()
+	sourceSize, err := io.Copy(io.MultiWriter(input, hasher), io.LimitReader(source, s.media.maxUploadBytes+1))
+	if closeErr := input.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sourceSize > s.media.maxUploadBytes {
+		// streamed uploads stop being read at the limit, so their size is unknown
+		return nil, exceeded("file size", float64(s.media.maxUploadBytes), 0, "bytes")
+	}
+	sourceHash := hex.EncodeToString(hasher.Sum(nil))
+	if err := s.checkDuplicate(sourceHash, uploader); err != nil {
+		return nil, err
+	}
+	// a full quota is rejected now; whether this upload fits is known after transcoding
+	if err := s.checkQuota(uploader, 0); err != nil {
+		return nil, err
 	}
 
-	//save input file
-	data, err := io.ReadAll(file)
+	// reject what we can't or won't transcode before spending time on it
+	probe, err := probeMedia(ctx, job, inputPath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return nil, jobStopped(ctx, &requestError{http.StatusUnsupportedMediaType, "unsupported_media", "not a readable video file: " + err.Error()})
 	}
-	err = os.WriteFile(inputPath, data, 0644)
+	if err := s.media.check(probe); err != nil {
+		return nil, err
+	}
+
+	version, outputDir, err := s.transcode(ctx, job, videoId, inputPath, tempDir, probe)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return nil, err
+	}
+	size, err := dirSize(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	if s.originals != nil {
+		// compression only makes the original smaller
+		size += sourceSize
+	}
+	if err := s.checkQuota(uploader, size); err != nil {
+		return nil, err
+	}
+	if err := s.storeVersion(videoId, outputDir); err != nil {
+		return nil, err
+	}
+	if err := s.keepOriginal(videoId, inputPath); err != nil {
+		return nil, err
+	}
+	// save the metadata
+	video := VideoMetadata{
+		Id:             videoId,
+		UploadedAt:     time.Now(),
+		Uploader:       uploader,
+		Visibility:     visibility,
+		AudioLanguages: audioLanguages(probe),
+		SourceHash:     sourceHash,
+		Version:        version,
+	}
+	err = s.metadataService.Create(video)
+	if err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
+// jobStopped returns why the job of ctx stopped, if it did, and err otherwise.
+// A killed ffmpeg only says "signal: killed"; the cause says why it was killed.
+func jobStopped(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	return err
+}
+
+// transcode transcodes the source at inputPath to DASH with the current
+// encoding profile and generates previews, as a new version of videoId. It
+// returns the version and the directory, under workDir, holding its files;
+// storeVersion stores them. workDir is scratch space that the caller removes.
+func (s *server) transcode(ctx context.Context, job *transcodeJob, videoId string, inputPath string, workDir string, probe *mediaProbe) (version string, outputDir string, err error) {
+	outputDir = filepath.Join(workDir, "out")
+	err = os.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return "", "", err
 	}
 
 	//	run ffmpeg
-	cmd := exec.Command("ffmpeg",
-		"-i", inputPath,
-		"-map", "0",
-		"-f", "dash",
-		filepath.Join(outputDir, "manifest.mpd"),
-	)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	version = newVersion()
+	if err := transcodeToDASH(ctx, job, inputPath, outputDir, probe, s.encoding, version); err != nil {
+		return "", "", jobStopped(ctx, err)
 	}
 
-	// store output files
+	// previews are nice to have; a video without them still plays
+	if err := generatePoster(ctx, job, inputPath, outputDir, version); err != nil {
+		log.Printf("Generating poster for %s: %v", videoId, err)
+	}
+	if err := generateThumbnails(ctx, job, inputPath, workDir, outputDir, version); err != nil {
+		log.Printf("Generating thumbnails for %s: %v", videoId, err)
+	}
+	// the previews don't fail the job, but a cancelled job still ends here
+	if ctx.Err() != nil {
+		return "", "", context.Cause(ctx)
+	}
+	return version, outputDir, nil
+}
+
+// storeVersion writes the files transcode left in outputDir as files of
+// videoId. Files of a version are never overwritten; viewers only see it once
+// the metadata points at it.
+func (s *server) storeVersion(videoId string, outputDir string) error {
 	entries, err := os.ReadDir(outputDir)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return err
 	}
 	for _, entry := range entries {
 		if entry.IsDir() {
@@ -174,23 +525,33 @@ func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
 		}
 		content, err := os.ReadFile(filepath.Join(outputDir, entry.Name()))
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+			return err
 		}
 		err = s.contentService.Write(videoId, entry.Name(), content)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+			return err
 		}
 	}
-	// save the metadata
-	err = s.metadataService.Create(videoId, time.Now())
+	return nil
+}
+
+// dirSize returns the total size of the files directly in dir.
+func dirSize(dir string) (int64, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return 0, err
 	}
-
-	http.Redirect(w, r, "/videos/"+videoId, http.StatusSeeOther)
+	var size int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return 0, err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+	}
+	return size, nil
 }
 
 func (s *server) handleVideo(w http.ResponseWriter, r *http.Request) {
@@ -205,18 +566,65 @@ func (s *server) handleVideo(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	if video == nil {
+	user := s.currentUser(r)
+	if video == nil || !canView(video, user) {
 		http.Error(w, "video not found (404)", http.StatusNotFound)
 		return
 	}
+	subtitles, err := s.listSubtitles(videoId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type SubtitleTrack struct {
+		Language string
+		Label    string
+		URL      string
+	}
+	var tracks []SubtitleTrack
+	for _, subtitle := range subtitles {
+		tracks = append(tracks, SubtitleTrack{
+			Language: subtitle.Language,
+			Label:    subtitle.Label,
+			URL:      s.signer.contentURL(videoId, subtitleFilename(subtitle.Language), time.Now()),
+		})
+	}
+
+	storageUsed := ""
+	if used := s.ownVideoUsage(video, user); used != nil {
+		storageUsed = formatSize(*used)
+	}
 
 	// prep the data
 	data := struct {
-		Id         string
-		UploadedAt string
+		Id            string
+		UploadedAt    string
+		Uploader      string
+		Visibility    Visibility
+		StorageUsed   string
+		ManifestURL   string
+		PosterURL     string
+		ThumbnailsURL string
+		EscapedId     string
+		Subtitles     []SubtitleTrack
+		// AudioLanguages labels the audio selector when dash.js can't tell
+		AudioLanguages []string
+		// CanEdit shows the subtitle management forms
+		CanEdit bool
 	}{
-		Id:         videoId,
-		UploadedAt: video.UploadedAt.Format("2006-01-02 15:04:05"),
+		Id:             videoId,
+		UploadedAt:     video.UploadedAt.Format("2006-01-02 15:04:05"),
+		Uploader:       video.Uploader,
+		Visibility:     video.Visibility,
+		StorageUsed:    storageUsed,
+		ManifestURL:    s.signer.contentURL(videoId, versionedFilename(video.Version, manifestFilename), time.Now()),
+		PosterURL:      s.signer.contentURL(videoId, versionedFilename(video.Version, posterFilename), time.Now()),
+		ThumbnailsURL:  s.signer.contentURL(videoId, versionedFilename(video.Version, thumbnailsFilename), time.Now()),
+		EscapedId:      url.PathEscape(videoId),
+		Subtitles:      tracks,
+		AudioLanguages: video.AudioLanguages,
+		CanEdit:        s.subtitles != nil && user != "" && user == video.Uploader,
 	}
 
 	tmpl := template.Must(template.New("video").Parse(videoHTML))
@@ -227,17 +635,26 @@ func (s *server) handleVideo(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *server) handleVideoContent(w http.ResponseWriter, r *http.Request) {
-	// parse /content/<videoId>/<filename>
+	// parse /content/<videoId>/<token>/<filename>
 	videoId := r.URL.Path[len("/content/"):]
 	parts := strings.Split(videoId, "/")
-	if len(parts) != 2 {
+	if len(parts) != 3 {
 		http.Error(w, "Invalid content path", http.StatusBadRequest)
 		return
 	}
 	videoId = parts[0]
-	filename := parts[1]
+	token := parts[1]
+	filename := parts[2]
 	log.Println("Video ID:", videoId, "Filename:", filename)
 
+	// the token was only handed out to someone allowed to see the video page, so
+	// it stands in for the session; this is what lets a CDN fetch private content
+	expiresAt, ok := s.signer.verify(videoId, token, time.Now())
+	if !ok {
+		http.Error(w, "content URL is invalid or has expired (403)", http.StatusForbidden)
+		return
+	}
+
 	// my added
 	data, err := s.contentService.Read(videoId, filename)
 	if err != nil {
@@ -250,10 +667,75 @@ func (s *server) handleVideoContent(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/dash+xml")
 	} else if strings.HasSuffix(filename, ".m4s") {
 		w.Header().Set("Content-Type", "video/iso.segment")
+	} else if strings.HasSuffix(filename, ".jpg") {
+		w.Header().Set("Content-Type", "image/jpeg")
+	} else if strings.HasSuffix(filename, ".vtt") {
+		w.Header().Set("Content-Type", "text/vtt")
 	} else {
 		w.Header().Set("Content-Type", "application/octet-stream")
 	}
+	// caches may keep the response for as long as the URL is valid
+	maxAge := int(time.Until(expiresAt).Seconds())
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
 
 	w.WriteHeader(http.StatusOK)
 	w.Write(data)
 }
+
+// duplicateError is an upload of a file that is already a video.
+type duplicateError struct {
+	*requestError
+	videoId string
+}
+
+func (e *duplicateError) Unwrap() error {
+	return e.requestError
+}
+
+// checkDuplicate rejects a file that was uploaded before, if uploader can
+// watch the earlier video; otherwise the upload goes ahead, so private videos
+// can't be detected by uploading copies of them.
+func (s *server) checkDuplicate(sourceHash string, uploader string) error {
+	videos, err := s.metadataService.List()
+	if err != nil {
+		return err
+	}
+	for _, video := range videos {
+		if video.SourceHash == sourceHash && canView(&video, uploader) {
+			return &duplicateError{
+				requestError: &requestError{http.StatusConflict, "duplicate", fmt.Sprintf("this file was already uploaded as %q", video.Id)},
+				videoId:      video.Id,
+			}
+		}
+	}
+	return nil
+}
+
+// deleteVideo removes a video's content and then its metadata. Content goes
+// first: if that fails the video is still listed and the delete can be retried.
+func (s *server) deleteVideo(videoId string) error {
+	if err := s.contentService.Delete(videoId); err != nil {
+		return fmt.Errorf("deleting content: %w", err)
+	}
+	if s.originals != nil {
+		if err := s.originals.Delete(videoId); err != nil {
+			return fmt.Errorf("deleting original: %w", err)
+		}
+	}
+	return s.metadataService.Delete(videoId)
+}
+
+// canView reports whether user ("" when anonymous) may watch video. Private
+// videos are hidden as if they didn't exist, so their ids can't be probed.
+func canView(video *VideoMetadata, user string) bool {
+	if video.Visibility == VisibilityPrivate {
+		return user != "" && user == video.Uploader
+	}
+	return true
+}
+
+// isListed reports whether video belongs in user's video list: public
+// videos, plus the user's own.
+func isListed(video *VideoMetadata, user string) bool {
+	return video.Visibility == VisibilityPublic || (user != "" && user == video.Uploader)
+}
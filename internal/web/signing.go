@@ -0,0 +1,76 @@
+// Signed, expiring content URLs
+
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultContentURLTTL is how long a content URL works when the config doesn't say.
+// It has to outlast the longest viewing session, since segments keep being fetched.
+const defaultContentURLTTL = 6 * time.Hour
+
+// urlSigner issues and checks the token in content URLs:
+//
+//	/content/<videoId>/<expires>-<signature>/<filename>
+//
+// The token covers the video, not the file, so the relative segment URLs
+// dash.js builds from the manifest carry the same token and stay valid.
+type urlSigner struct {
+	key []byte
+	ttl time.Duration
+}
+
+// newURLSigner uses a random key when key is empty. URLs signed with it only
+// work on this process and stop working when it restarts.
+func newURLSigner(key []byte, ttl time.Duration) *urlSigner {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key) // never fails
+	}
+	if ttl <= 0 {
+		ttl = defaultContentURLTTL
+	}
+	return &urlSigner{key: key, ttl: ttl}
+}
+
+func (u *urlSigner) signature(videoId string, expires int64) string {
+	mac := hmac.New(sha256.New, u.key)
+	mac.Write([]byte(videoId + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// token returns a token for videoId that expires ttl after now.
+func (u *urlSigner) token(videoId string, now time.Time) string {
+	expires := now.Add(u.ttl).Unix()
+	return strconv.FormatInt(expires, 10) + "-" + u.signature(videoId, expires)
+}
+
+// verify returns when token expires, and whether it is a valid, unexpired token for videoId.
+func (u *urlSigner) verify(videoId string, token string, now time.Time) (time.Time, bool) {
+	expiresPart, sig, ok := strings.Cut(token, "-")
+	if !ok {
+		return time.Time{}, false
+	}
+	expires, err := strconv.ParseInt(expiresPart, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if !hmac.Equal([]byte(sig), []byte(u.signature(videoId, expires))) {
+		return time.Time{}, false
+	}
+	expiresAt := time.Unix(expires, 0)
+	return expiresAt, now.Before(expiresAt)
+}
+
+// contentURL returns a signed URL for one file of a video.
+func (u *urlSigner) contentURL(videoId string, filename string, now time.Time) string {
+	return "/content/" + url.PathEscape(videoId) + "/" + u.token(videoId, now) + "/" + url.PathEscape(filename)
+}
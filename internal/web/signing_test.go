@@ -0,0 +1,63 @@
+package web
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSignerVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	signer := newURLSigner([]byte("test key"), time.Hour)
+	token := signer.token("a", now)
+	expiresPart, sig, _ := strings.Cut(token, "-")
+	tampered := "A" + sig[1:]
+	if tampered == sig {
+		tampered = "B" + sig[1:]
+	}
+
+	tests := []struct {
+		name    string
+		signer  *urlSigner
+		videoId string
+		token   string
+		now     time.Time
+		valid   bool
+	}{
+		{"valid", signer, "a", token, now, true},
+		{"just before expiry", signer, "a", token, now.Add(time.Hour - time.Second), true},
+		{"expired", signer, "a", token, now.Add(time.Hour), false},
+		{"other video", signer, "b", token, now, false},
+		{"other key", newURLSigner([]byte("other key"), time.Hour), "a", token, now, false},
+		{"extended expiry", signer, "a", "9999999999-" + sig, now, false},
+		{"tampered signature", signer, "a", expiresPart + "-" + tampered, now, false},
+		{"no separator", signer, "a", expiresPart + sig, now, false},
+		{"bad expiry", signer, "a", "soon-" + sig, now, false},
+		{"empty", signer, "a", "", now, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, valid := test.signer.verify(test.videoId, test.token, test.now)
+			if valid != test.valid {
+				t.Errorf("verify(%q, %q) = %v, want %v", test.videoId, test.token, valid, test.valid)
+			}
+		})
+	}
+
+	expires, _ := signer.verify("a", token, now)
+	if want := now.Add(time.Hour); !expires.Equal(want) {
+		t.Errorf("token expires at %v, want %v", expires, want)
+	}
+}
+
+// Content URLs carry the token as a path segment, with the video and file escaped.
+func TestContentURL(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	signer := newURLSigner([]byte("test key"), time.Hour)
+
+	url := signer.contentURL("my video", "v1/manifest.mpd", now)
+	want := "/content/my%20video/" + signer.token("my video", now) + "/v1%2Fmanifest.mpd"
+	if url != want {
+		t.Errorf("contentURL = %q, want %q", url, want)
+	}
+}
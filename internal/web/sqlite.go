@@ -5,6 +5,7 @@ package web
 import (
 	"database/sql"
 	_ "github.com/mattn/go-sqlite3"
+	"strings"
 	"time"
 )
 
@@ -27,33 +28,184 @@ func NewSQLiteVideoMetadataService(dbPath string) (*SQLiteVideoMetadataService,
 	if err != nil {
 		return nil, err
 	}
+	err = addColumnIfMissing(db, "video_metadata", "uploader", `TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return nil, err
+	}
+	err = addColumnIfMissing(db, "video_metadata", "visibility", `TEXT NOT NULL DEFAULT 'public'`)
+	if err != nil {
+		return nil, err
+	}
+	// comma-separated, in manifest order
+	err = addColumnIfMissing(db, "video_metadata", "audio_languages", `TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return nil, err
+	}
+	err = addColumnIfMissing(db, "video_metadata", "source_hash", `TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return nil, err
+	}
+	err = addColumnIfMissing(db, "video_metadata", "version", `TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return nil, err
+	}
+
+	createUserTables := `CREATE TABLE IF NOT EXISTS users (
+		username TEXT PRIMARY KEY,
+		password_hash TEXT NOT NULL,
+		created_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS sessions (
+		token_hash TEXT PRIMARY KEY,
+		username TEXT NOT NULL,
+		expires_at DATETIME
+	);`
+	_, err = db.Exec(createUserTables)
+	if err != nil {
+		return nil, err
+	}
+
+	createSubtitleTable := `CREATE TABLE IF NOT EXISTS subtitles (
+		video_id TEXT NOT NULL,
+		language TEXT NOT NULL,
+		label TEXT NOT NULL,
+		PRIMARY KEY (video_id, language)
+	);`
+	_, err = db.Exec(createSubtitleTable)
+	if err != nil {
+		return nil, err
+	}
+
+	createJobTable := `CREATE TABLE IF NOT EXISTS jobs (
+		id TEXT PRIMARY KEY,
+		video_id TEXT NOT NULL,
+		uploader TEXT NOT NULL,
+		state TEXT NOT NULL,
+		progress REAL NOT NULL,
+		error TEXT NOT NULL,
+		log TEXT NOT NULL,
+		started_at DATETIME,
+		finished_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS jobs_by_uploader ON jobs (uploader, started_at);`
+	_, err = db.Exec(createJobTable)
+	if err != nil {
+		return nil, err
+	}
+
+	createBlobTables := `CREATE TABLE IF NOT EXISTS content_files (
+		video_id TEXT NOT NULL,
+		filename TEXT NOT NULL,
+		blob TEXT NOT NULL,
+		PRIMARY KEY (video_id, filename)
+	);
+	CREATE TABLE IF NOT EXISTS blobs (
+		hash TEXT PRIMARY KEY,
+		size INTEGER NOT NULL,
+		refs INTEGER NOT NULL
+	);`
+	_, err = db.Exec(createBlobTables)
+	if err != nil {
+		return nil, err
+	}
+	// links made before this column existed read as the zero time
+	err = addColumnIfMissing(db, "content_files", "linked_at", `DATETIME`)
+	if err != nil {
+		return nil, err
+	}
+
+	createUsageTable := `CREATE TABLE IF NOT EXISTS file_sizes (
+		store TEXT NOT NULL,
+		video_id TEXT NOT NULL,
+		filename TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		PRIMARY KEY (store, video_id, filename)
+	);
+	CREATE INDEX IF NOT EXISTS file_sizes_by_video ON file_sizes (video_id);`
+	_, err = db.Exec(createUsageTable)
+	if err != nil {
+		return nil, err
+	}
+
+	createVersionTable := `CREATE TABLE IF NOT EXISTS retired_versions (
+		video_id TEXT NOT NULL,
+		version TEXT NOT NULL,
+		retired_at DATETIME NOT NULL,
+		PRIMARY KEY (video_id, version)
+	);`
+	_, err = db.Exec(createVersionTable)
+	if err != nil {
+		return nil, err
+	}
 	return &SQLiteVideoMetadataService{db: db}, nil
 }
 
+// addColumnIfMissing upgrades tables created by older versions in place.
+func addColumnIfMissing(db *sql.DB, table string, column string, decl string) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + decl)
+	return err
+}
+
 //CREATE
 
-func (s *SQLiteVideoMetadataService) Create(videoId string, uploadedAt time.Time) error {
-	_, err := s.db.Exec(`INSERT INTO video_metadata (id, uploaded_at) VALUES (?, ?)`, videoId, uploadedAt)
+func (s *SQLiteVideoMetadataService) Create(video VideoMetadata) error {
+	if video.Visibility == "" {
+		video.Visibility = VisibilityPublic
+	}
+	_, err := s.db.Exec(`INSERT INTO video_metadata (id, uploaded_at, uploader, visibility, audio_languages, source_hash, version) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		video.Id, video.UploadedAt, video.Uploader, video.Visibility, strings.Join(video.AudioLanguages, ","), video.SourceHash, video.Version)
 	return err
 }
 
+// scanVideo reads a row of the columns in videoColumns.
+func scanVideo(row interface{ Scan(...any) error }) (*VideoMetadata, error) {
+	var metadata VideoMetadata
+	var audioLanguages string
+	err := row.Scan(&metadata.Id, &metadata.UploadedAt, &metadata.Uploader, &metadata.Visibility, &audioLanguages, &metadata.SourceHash, &metadata.Version)
+	if err != nil {
+		return nil, err
+	}
+	if audioLanguages != "" {
+		metadata.AudioLanguages = strings.Split(audioLanguages, ",")
+	}
+	return &metadata, nil
+}
+
+const videoColumns = `id, uploaded_at, uploader, visibility, audio_languages, source_hash, version`
+
 // READ
 func (s *SQLiteVideoMetadataService) Read(id string) (*VideoMetadata, error) {
-	row := s.db.QueryRow(`SELECT id, uploaded_at FROM video_metadata WHERE id = ?`, id)
+	row := s.db.QueryRow(`SELECT `+videoColumns+` FROM video_metadata WHERE id = ?`, id)
 
-	var metadata VideoMetadata
-	err := row.Scan(&metadata.Id, &metadata.UploadedAt)
+	metadata, err := scanVideo(row)
 	if err == sql.ErrNoRows {
 		return nil, nil // video not found
 	} else if err != nil {
 		return nil, err
 	}
-	return &metadata, nil
+	return metadata, nil
 }
 
 // LIST
 func (s *SQLiteVideoMetadataService) List() ([]VideoMetadata, error) {
-	rows, err := s.db.Query(`SELECT id, uploaded_at FROM video_metadata`)
+	rows, err := s.db.Query(`SELECT ` + videoColumns + ` FROM video_metadata`)
 	if err != nil {
 		return nil, err
 	}
@@ -61,14 +213,435 @@ func (s *SQLiteVideoMetadataService) List() ([]VideoMetadata, error) {
 
 	var videos []VideoMetadata
 	for rows.Next() {
-		var m VideoMetadata
-		if err := rows.Scan(&m.Id, &m.UploadedAt); err != nil {
+		m, err := scanVideo(rows)
+		if err != nil {
 			return nil, err
 		}
-		videos = append(videos, m)
+		videos = append(videos, *m)
 	}
 	return videos, nil
 }
 
+// UPDATE
+func (s *SQLiteVideoMetadataService) SetVisibility(id string, visibility Visibility) error {
+	_, err := s.db.Exec(`UPDATE video_metadata SET visibility = ? WHERE id = ?`, visibility, id)
+	return err
+}
+
+// SET VERSION
+func (s *SQLiteVideoMetadataService) SetVersion(id string, from string, to string, audioLanguages []string) (bool, error) {
+	result, err := s.db.Exec(`UPDATE video_metadata SET version = ?, audio_languages = ? WHERE id = ? AND version = ?`,
+		to, strings.Join(audioLanguages, ","), id, from)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	return updated > 0, err
+}
+
+// DELETE
+func (s *SQLiteVideoMetadataService) Delete(id string) error {
+	_, err := s.db.Exec(`DELETE FROM video_metadata WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`DELETE FROM subtitles WHERE video_id = ?`, id)
+	return err
+}
+
+// SET SUBTITLE
+func (s *SQLiteVideoMetadataService) SetSubtitle(videoId string, subtitle Subtitle) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO subtitles (video_id, language, label) VALUES (?, ?, ?)`,
+		videoId, subtitle.Language, subtitle.Label)
+	return err
+}
+
+// LIST SUBTITLES
+func (s *SQLiteVideoMetadataService) ListSubtitles(videoId string) ([]Subtitle, error) {
+	rows, err := s.db.Query(`SELECT language, label FROM subtitles WHERE video_id = ? ORDER BY language`, videoId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subtitles []Subtitle
+	for rows.Next() {
+		var subtitle Subtitle
+		if err := rows.Scan(&subtitle.Language, &subtitle.Label); err != nil {
+			return nil, err
+		}
+		subtitles = append(subtitles, subtitle)
+	}
+	return subtitles, rows.Err()
+}
+
+// DELETE SUBTITLE
+func (s *SQLiteVideoMetadataService) DeleteSubtitle(videoId string, language string) error {
+	_, err := s.db.Exec(`DELETE FROM subtitles WHERE video_id = ? AND language = ?`, videoId, language)
+	return err
+}
+
+// SAVE JOB
+func (s *SQLiteVideoMetadataService) SaveJob(job JobRecord) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO jobs (id, video_id, uploader, state, progress, error, log, started_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.Id, job.VideoId, job.Uploader, job.State, job.Progress, job.Error, job.Log, job.StartedAt.UTC(), job.FinishedAt.UTC())
+	if err != nil || job.State == JobRunning {
+		return err
+	}
+
+	// keep the uploader's newest finished jobs; each can carry up to maxJobLog of log
+	_, err = s.db.Exec(`DELETE FROM jobs WHERE uploader = ? AND state != ? AND id NOT IN (
+			SELECT id FROM jobs WHERE uploader = ? AND state != ? ORDER BY started_at DESC LIMIT ?)`,
+		job.Uploader, JobRunning, job.Uploader, JobRunning, maxJobsPerUploader)
+	return err
+}
+
+// scanJob reads a row of the columns in jobColumns.
+func scanJob(row interface{ Scan(...any) error }) (*JobRecord, error) {
+	var job JobRecord
+	err := row.Scan(&job.Id, &job.VideoId, &job.Uploader, &job.State, &job.Progress, &job.Error, &job.Log, &job.StartedAt, &job.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+const jobColumns = `id, video_id, uploader, state, progress, error, log, started_at, finished_at`
+
+// READ JOB
+func (s *SQLiteVideoMetadataService) ReadJob(id string) (*JobRecord, error) {
+	row := s.db.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id)
+
+	job, err := scanJob(row)
+	if err == sql.ErrNoRows {
+		return nil, nil // job not found
+	} else if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// LIST JOBS
+func (s *SQLiteVideoMetadataService) ListJobs(uploader string, limit int) ([]JobRecord, error) {
+	rows, err := s.db.Query(`SELECT `+jobColumns+` FROM jobs WHERE uploader = ? ORDER BY started_at DESC LIMIT ?`, uploader, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []JobRecord
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// LINK BLOB
+func (s *SQLiteVideoMetadataService) LinkBlob(videoId string, filename string, hash string, size int64) (string, int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", 0, err
+	}
+	defer tx.Rollback()
+
+	oldHash, _, err := unlinkBlob(tx, videoId, filename)
+	if err != nil {
+		return "", 0, err
+	}
+	_, err = tx.Exec(`INSERT INTO content_files (video_id, filename, blob, linked_at) VALUES (?, ?, ?, ?)`, videoId, filename, hash, time.Now().UTC())
+	if err != nil {
+		return "", 0, err
+	}
+	_, err = tx.Exec(`INSERT INTO blobs (hash, size, refs) VALUES (?, ?, 1) ON CONFLICT (hash) DO UPDATE SET refs = refs + 1`, hash, size)
+	if err != nil {
+		return "", 0, err
+	}
+	// counted after linking, in case the file is relinked to the same blob
+	var oldRefs int
+	if oldHash != "" {
+		err = tx.QueryRow(`SELECT refs FROM blobs WHERE hash = ?`, oldHash).Scan(&oldRefs)
+		if err != nil && err != sql.ErrNoRows {
+			return "", 0, err
+		}
+	}
+	return oldHash, oldRefs, tx.Commit()
+}
+
+// UNLINK BLOB
+func (s *SQLiteVideoMetadataService) UnlinkBlob(videoId string, filename string) (string, int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", 0, err
+	}
+	defer tx.Rollback()
+
+	hash, refs, err := unlinkBlob(tx, videoId, filename)
+	if err != nil {
+		return "", 0, err
+	}
+	return hash, refs, tx.Commit()
+}
+
+// unlinkBlob forgets a file within tx, like UnlinkBlob.
+func unlinkBlob(tx *sql.Tx, videoId string, filename string) (string, int, error) {
+	var hash string
+	err := tx.QueryRow(`SELECT blob FROM content_files WHERE video_id = ? AND filename = ?`, videoId, filename).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", 0, nil // not linked
+	} else if err != nil {
+		return "", 0, err
+	}
+	_, err = tx.Exec(`DELETE FROM content_files WHERE video_id = ? AND filename = ?`, videoId, filename)
+	if err != nil {
+		return "", 0, err
+	}
+	var refs int
+	err = tx.QueryRow(`UPDATE blobs SET refs = refs - 1 WHERE hash = ? RETURNING refs`, hash).Scan(&refs)
+	if err != nil {
+		return "", 0, err
+	}
+	if refs <= 0 {
+		_, err = tx.Exec(`DELETE FROM blobs WHERE hash = ?`, hash)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+	return hash, refs, nil
+}
+
+// BLOB OF
+func (s *SQLiteVideoMetadataService) BlobOf(videoId string, filename string) (string, error) {
+	var hash string
+	err := s.db.QueryRow(`SELECT blob FROM content_files WHERE video_id = ? AND filename = ?`, videoId, filename).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil // not linked
+	}
+	return hash, err
+}
+
+// BLOB REFS
+func (s *SQLiteVideoMetadataService) BlobRefs(hash string) (int, error) {
+	var refs int
+	err := s.db.QueryRow(`SELECT refs FROM blobs WHERE hash = ?`, hash).Scan(&refs)
+	if err == sql.ErrNoRows {
+		return 0, nil // not stored
+	}
+	return refs, err
+}
+
+// LIST BLOB FILES
+func (s *SQLiteVideoMetadataService) ListBlobFiles(videoId string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT filename FROM content_files WHERE video_id = ? ORDER BY filename`, videoId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var filenames []string
+	for rows.Next() {
+		var filename string
+		if err := rows.Scan(&filename); err != nil {
+			return nil, err
+		}
+		filenames = append(filenames, filename)
+	}
+	return filenames, rows.Err()
+}
+
+// LIST LINKED FILES
+func (s *SQLiteVideoMetadataService) ListLinkedFiles(videoId string) ([]ContentFile, error) {
+	rows, err := s.db.Query(`SELECT f.video_id, f.filename, b.size, f.linked_at FROM content_files f JOIN blobs b ON b.hash = f.blob
+		WHERE ? = '' OR f.video_id = ?`, videoId, videoId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []ContentFile
+	for rows.Next() {
+		var file ContentFile
+		var linkedAt sql.NullTime
+		if err := rows.Scan(&file.VideoId, &file.Filename, &file.Size, &linkedAt); err != nil {
+			return nil, err
+		}
+		file.ModifiedAt = linkedAt.Time
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+// SET FILE SIZE
+func (s *SQLiteVideoMetadataService) SetFileSize(store string, videoId string, filename string, size int64) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO file_sizes (store, video_id, filename, size) VALUES (?, ?, ?, ?)`,
+		store, videoId, filename, size)
+	return err
+}
+
+// DELETE FILE SIZE
+func (s *SQLiteVideoMetadataService) DeleteFileSize(store string, videoId string, filename string) error {
+	_, err := s.db.Exec(`DELETE FROM file_sizes WHERE store = ? AND video_id = ? AND filename = ?`, store, videoId, filename)
+	return err
+}
+
+// DELETE VIDEO SIZES
+func (s *SQLiteVideoMetadataService) DeleteVideoSizes(store string, videoId string) error {
+	_, err := s.db.Exec(`DELETE FROM file_sizes WHERE store = ? AND video_id = ?`, store, videoId)
+	return err
+}
+
+// VIDEO USAGE
+func (s *SQLiteVideoMetadataService) VideoUsage(videoId string) (int64, bool, error) {
+	var usage, files int64
+	err := s.db.QueryRow(`SELECT COALESCE(SUM(size), 0), COUNT(*) FROM file_sizes WHERE video_id = ?`, videoId).Scan(&usage, &files)
+	return usage, files > 0, err
+}
+
+// UPLOADER USAGE
+func (s *SQLiteVideoMetadataService) UploaderUsage(uploader string) (int64, error) {
+	var usage int64
+	err := s.db.QueryRow(`SELECT COALESCE(SUM(f.size), 0) FROM file_sizes f JOIN video_metadata m ON m.id = f.video_id WHERE m.uploader = ?`,
+		uploader).Scan(&usage)
+	return usage, err
+}
+
+// UPLOADER VIDEO USAGE
+func (s *SQLiteVideoMetadataService) UploaderVideoUsage(uploader string) (map[string]int64, error) {
+	rows, err := s.db.Query(`SELECT f.video_id, SUM(f.size) FROM file_sizes f JOIN video_metadata m ON m.id = f.video_id
+		WHERE m.uploader = ? GROUP BY f.video_id`, uploader)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := make(map[string]int64)
+	for rows.Next() {
+		var videoId string
+		var used int64
+		if err := rows.Scan(&videoId, &used); err != nil {
+			return nil, err
+		}
+		usage[videoId] = used
+	}
+	return usage, rows.Err()
+}
+
+// LIST TRACKED VIDEOS
+func (s *SQLiteVideoMetadataService) ListTrackedVideos(store string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT DISTINCT video_id FROM file_sizes WHERE store = ?`, store)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videoIds []string
+	for rows.Next() {
+		var videoId string
+		if err := rows.Scan(&videoId); err != nil {
+			return nil, err
+		}
+		videoIds = append(videoIds, videoId)
+	}
+	return videoIds, rows.Err()
+}
+
+// RETIRE VERSION
+func (s *SQLiteVideoMetadataService) RetireVersion(version RetiredVersion) error {
+	// times are stored in UTC so they compare correctly as text
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO retired_versions (video_id, version, retired_at) VALUES (?, ?, ?)`,
+		version.VideoId, version.Version, version.RetiredAt.UTC())
+	return err
+}
+
+// LIST RETIRED VERSIONS
+func (s *SQLiteVideoMetadataService) ListRetiredVersions(before time.Time) ([]RetiredVersion, error) {
+	rows, err := s.db.Query(`SELECT video_id, version, retired_at FROM retired_versions WHERE retired_at < ? ORDER BY retired_at`, before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []RetiredVersion
+	for rows.Next() {
+		var version RetiredVersion
+		if err := rows.Scan(&version.VideoId, &version.Version, &version.RetiredAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// FORGET VERSION
+func (s *SQLiteVideoMetadataService) ForgetVersion(videoId string, version string) error {
+	_, err := s.db.Exec(`DELETE FROM retired_versions WHERE video_id = ? AND version = ?`, videoId, version)
+	return err
+}
+
+// CREATE USER
+func (s *SQLiteVideoMetadataService) CreateUser(user User) error {
+	_, err := s.db.Exec(`INSERT INTO users (username, password_hash, created_at) VALUES (?, ?, ?)`,
+		user.Username, user.PasswordHash, user.CreatedAt)
+	return err
+}
+
+// READ USER
+func (s *SQLiteVideoMetadataService) ReadUser(username string) (*User, error) {
+	row := s.db.QueryRow(`SELECT username, password_hash, created_at FROM users WHERE username = ?`, username)
+
+	var user User
+	err := row.Scan(&user.Username, &user.PasswordHash, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // user not found
+	} else if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CREATE SESSION
+func (s *SQLiteVideoMetadataService) CreateSession(tokenHash string, session Session) error {
+	// expired sessions are cleared out whenever someone logs in; times are
+	// stored in UTC so they compare correctly as text
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE expires_at < ?`, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO sessions (token_hash, username, expires_at) VALUES (?, ?, ?)`,
+		tokenHash, session.Username, session.ExpiresAt.UTC())
+	return err
+}
+
+// READ SESSION
+func (s *SQLiteVideoMetadataService) ReadSession(tokenHash string) (*Session, error) {
+	row := s.db.QueryRow(`SELECT username, expires_at FROM sessions WHERE token_hash = ? AND expires_at > ?`,
+		tokenHash, time.Now().UTC())
+
+	var session Session
+	err := row.Scan(&session.Username, &session.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil // no live session
+	} else if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DELETE SESSION
+func (s *SQLiteVideoMetadataService) DeleteSession(tokenHash string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE token_hash = ?`, tokenHash)
+	return err
+}
+
 // Uncomment the following line to ensure SQLiteVideoMetadataService implements VideoMetadataService
 var _ VideoMetadataService = (*SQLiteVideoMetadataService)(nil)
+var _ UserService = (*SQLiteVideoMetadataService)(nil)
+var _ SubtitleService = (*SQLiteVideoMetadataService)(nil)
+var _ JobService = (*SQLiteVideoMetadataService)(nil)
+var _ BlobIndex = (*SQLiteVideoMetadataService)(nil)
+var _ VersionService = (*SQLiteVideoMetadataService)(nil)
+var _ UsageService = (*SQLiteVideoMetadataService)(nil)
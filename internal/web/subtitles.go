@@ -0,0 +1,239 @@
+// Subtitle and caption tracks: SRT/WebVTT upload, conversion and management
+
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxSubtitleSize bounds an uploaded subtitle file.
+const maxSubtitleSize = 5 << 20
+
+var (
+	languagePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+	// srtTiming matches "00:00:01,000 --> 00:00:04,000", optionally followed by position settings
+	srtTiming = regexp.MustCompile(`^(\d{2,}:\d{2}:\d{2}),(\d{3})\s+-->\s+(\d{2,}:\d{2}:\d{2}),(\d{3})(.*)$`)
+)
+
+// subtitleFilename is where a track is stored through VideoContentService.
+func subtitleFilename(language string) string {
+	return "subtitles-" + language + ".vtt"
+}
+
+// toWebVTT returns data as WebVTT, converting it if it is SRT.
+func toWebVTT(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, &requestError{http.StatusBadRequest, "invalid_subtitles", "subtitle files must be UTF-8"}
+	}
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	if text == "WEBVTT" || strings.HasPrefix(text, "WEBVTT\n") || strings.HasPrefix(text, "WEBVTT ") || strings.HasPrefix(text, "WEBVTT\t") {
+		return []byte(text), nil
+	}
+
+	// SRT differs from WebVTT in its missing header and the comma before the milliseconds;
+	// cue numbers are valid WebVTT cue identifiers and can stay
+	lines := strings.Split(text, "\n")
+	cues := 0
+	for i, line := range lines {
+		if m := srtTiming.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			lines[i] = m[1] + "." + m[2] + " --> " + m[3] + "." + m[4] + m[5]
+			cues++
+		}
+	}
+	if cues == 0 {
+		return nil, &requestError{http.StatusBadRequest, "invalid_subtitles", "not an SRT or WebVTT file"}
+	}
+	return []byte("WEBVTT\n\n" + strings.TrimLeft(strings.Join(lines, "\n"), "\n")), nil
+}
+
+// saveSubtitle converts and stores a track for video, replacing any track in the same language.
+func (s *server) saveSubtitle(video *VideoMetadata, language string, label string, data []byte) (*Subtitle, error) {
+	if s.subtitles == nil {
+		return nil, &requestError{http.StatusNotImplemented, "not_implemented", "subtitles are not supported by this metadata store"}
+	}
+	if !languagePattern.MatchString(language) {
+		return nil, &requestError{http.StatusBadRequest, "invalid_language", "language must be a tag such as en or pt-BR"}
+	}
+	if label == "" {
+		label = language
+	}
+	vtt, err := toWebVTT(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.contentService.Write(video.Id, subtitleFilename(language), vtt); err != nil {
+		return nil, err
+	}
+	subtitle := Subtitle{Language: language, Label: label}
+	if err := s.subtitles.SetSubtitle(video.Id, subtitle); err != nil {
+		return nil, err
+	}
+	return &subtitle, nil
+}
+
+// removeSubtitle deletes a track from the metadata first, so players stop offering it
+// even if the file can't be deleted.
+func (s *server) removeSubtitle(video *VideoMetadata, language string) error {
+	if s.subtitles == nil {
+		return &requestError{http.StatusNotImplemented, "not_implemented", "subtitles are not supported by this metadata store"}
+	}
+	subtitles, err := s.subtitles.ListSubtitles(video.Id)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, subtitle := range subtitles {
+		found = found || subtitle.Language == language
+	}
+	if !found {
+		return &requestError{http.StatusNotFound, "not_found", "the video has no subtitles in that language"}
+	}
+	if err := s.subtitles.DeleteSubtitle(video.Id, language); err != nil {
+		return err
+	}
+	return s.contentService.DeleteFile(video.Id, subtitleFilename(language))
+}
+
+// listSubtitles returns the tracks of a video, or none when the store doesn't support them.
+func (s *server) listSubtitles(videoId string) ([]Subtitle, error) {
+	if s.subtitles == nil {
+		return nil, nil
+	}
+	return s.subtitles.ListSubtitles(videoId)
+}
+
+// handleUploadSubtitle handles the subtitle form on the video page.
+func (s *server) handleUploadSubtitle(w http.ResponseWriter, r *http.Request) {
+	video, err := s.findOwnVideo(r)
+	if err != nil {
+		status, _ := errorStatus(err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	err = r.ParseMultipartForm(maxSubtitleSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxSubtitleSize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(data) > maxSubtitleSize {
+		http.Error(w, "subtitle file is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	_, err = s.saveSubtitle(video, r.FormValue("language"), r.FormValue("label"), data)
+	if err != nil {
+		status, _ := errorStatus(err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	http.Redirect(w, r, "/videos/"+url.PathEscape(video.Id), http.StatusSeeOther)
+}
+
+// handleDeleteSubtitle handles the remove buttons on the video page.
+func (s *server) handleDeleteSubtitle(w http.ResponseWriter, r *http.Request) {
+	video, err := s.findOwnVideo(r)
+	if err == nil {
+		err = s.removeSubtitle(video, r.PathValue("language"))
+	}
+	if err != nil {
+		status, _ := errorStatus(err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	http.Redirect(w, r, "/videos/"+url.PathEscape(video.Id), http.StatusSeeOther)
+}
+
+// apiSubtitle is the JSON form of a subtitle track.
+type apiSubtitle struct {
+	Language string `json:"language"`
+	Label    string `json:"label"`
+	// URL is a signed WebVTT URL, valid for a limited time
+	URL string `json:"url"`
+}
+
+func (s *server) apiSubtitle(videoId string, subtitle Subtitle) apiSubtitle {
+	return apiSubtitle{
+		Language: subtitle.Language,
+		Label:    subtitle.Label,
+		URL:      s.signer.contentURL(videoId, subtitleFilename(subtitle.Language), time.Now()),
+	}
+}
+
+// GET /api/videos/{id}/subtitles
+func (s *server) handleAPIListSubtitles(w http.ResponseWriter, r *http.Request) {
+	video, err := s.findVideo(r, s.currentUser(r))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	subtitles, err := s.listSubtitles(video.Id)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	list := []apiSubtitle{}
+	for _, subtitle := range subtitles {
+		list = append(list, s.apiSubtitle(video.Id, subtitle))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// PUT /api/videos/{id}/subtitles/{language}?label= takes an SRT or WebVTT body.
+func (s *server) handleAPIPutSubtitle(w http.ResponseWriter, r *http.Request) {
+	video, err := s.findOwnVideo(r)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxSubtitleSize+1))
+	if err != nil {
+		writeAPIError(w, &requestError{http.StatusBadRequest, "invalid_body", err.Error()})
+		return
+	}
+	if len(data) > maxSubtitleSize {
+		writeAPIError(w, &requestError{http.StatusRequestEntityTooLarge, "too_large", "subtitle file is too large"})
+		return
+	}
+	subtitle, err := s.saveSubtitle(video, r.PathValue("language"), r.URL.Query().Get("label"), data)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.apiSubtitle(video.Id, *subtitle))
+}
+
+// DELETE /api/videos/{id}/subtitles/{language}
+func (s *server) handleAPIDeleteSubtitle(w http.ResponseWriter, r *http.Request) {
+	video, err := s.findOwnVideo(r)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if err := s.removeSubtitle(video, r.PathValue("language")); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToWebVTT(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		// error code when the input is rejected
+		code string
+	}{
+		{
+			name:  "srt",
+			input: "1\n00:00:01,000 --> 00:00:04,500\nHello\n\n2\n00:00:05,000 --> 00:00:06,000\nWorld\n",
+			want:  "WEBVTT\n\n1\n00:00:01.000 --> 00:00:04.500\nHello\n\n2\n00:00:05.000 --> 00:00:06.000\nWorld\n",
+		},
+		{
+			name:  "srt with crlf, bom and position",
+			input: "\uFEFF1\r\n00:00:01,000 --> 00:00:02,000 X1:10 X2:20\r\nHi\r\n",
+			want:  "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000 X1:10 X2:20\nHi\n",
+		},
+		{
+			name:  "srt past 99 hours",
+			input: "100:00:00,000 --> 100:00:01,000\nLate\n",
+			want:  "WEBVTT\n\n100:00:00.000 --> 100:00:01.000\nLate\n",
+		},
+		{
+			name:  "webvtt kept",
+			input: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHi\n",
+			want:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHi\n",
+		},
+		{
+			name:  "webvtt with header text",
+			input: "WEBVTT - English\r\n\r\n00:00:01.000 --> 00:00:02.000\r\nHi\r\n",
+			want:  "WEBVTT - English\n\n00:00:01.000 --> 00:00:02.000\nHi\n",
+		},
+		{name: "no cues", input: "just some text\n", code: "invalid_subtitles"},
+		{name: "empty", input: "", code: "invalid_subtitles"},
+		{name: "webvtt lookalike", input: "WEBVTTX\n", code: "invalid_subtitles"},
+		{name: "not utf-8", input: "1\n00:00:01,000 --> 00:00:02,000\n\xff\xfe\n", code: "invalid_subtitles"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := toWebVTT([]byte(test.input))
+			if test.code != "" {
+				var reqErr *requestError
+				if !errors.As(err, &reqErr) || reqErr.code != test.code {
+					t.Fatalf("toWebVTT(%q) error = %v, want %s", test.input, err, test.code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("toWebVTT(%q) error = %v", test.input, err)
+			}
+			if string(got) != test.want {
+				t.Errorf("toWebVTT(%q) = %q, want %q", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSubtitleLanguage(t *testing.T) {
+	tests := []struct {
+		language string
+		valid    bool
+	}{
+		{"en", true},
+		{"pt-BR", true},
+		{"zh-Hant-TW", true},
+		{"fil", true},
+		{"e", false},
+		{"english", false},
+		{"en_US", false},
+		{"../en", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if valid := languagePattern.MatchString(test.language); valid != test.valid {
+			t.Errorf("language %q valid = %v, want %v", test.language, valid, test.valid)
+		}
+	}
+}
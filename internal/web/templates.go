@@ -1,55 +1,268 @@
-// Lab 7, 8, 9: Use these templates to render the web pages
-
-package web
-
-const indexHTML = `
-<!DOCTYPE html>
-<html>
-  <head>
-    <meta charset="UTF-8" />
-    <title>TritonTube</title>
-  </head>
-  <body>
-    <h1>Welcome to TritonTube</h1>
-    <h2>Upload an MP4 Video</h2>
-    <form action="/upload" method="post" enctype="multipart/form-data">
-      <input type="file" name="file" accept="video/mp4" required />
-      <input type="submit" value="Upload" />
-    </form>
-    <h2>Watchlist</h2>
-    <ul>
-      {{range .}}
-      <li>
-        <a href="/videos/{{.EscapedId}}">{{.Id}} ({{.UploadTime}})</a>
-      </li>
-      {{else}}
-      <li>No videos uploaded yet.</li>
-      {{end}}
-    </ul>
-  </body>
-</html>
-`
-
-const videoHTML = `
-<!DOCTYPE html>
-<html>
-  <head>
-    <meta charset="UTF-8" />
-    <title>{{.Id}} - TritonTube</title>
-    <script src="https://cdn.dashjs.org/latest/dash.all.min.js"></script>
-  </head>
-  <body>
-    <h1>{{.Id}}</h1>
-	  <p>Uploaded at: {{.UploadedAt}}</p>
-
-    <video id="dashPlayer" controls style="width: 640px; height: 360px"></video>
-    <script>
-      var url = "/content/{{.Id}}/manifest.mpd";
-      var player = dashjs.MediaPlayer().create();
-      player.initialize(document.querySelector("#dashPlayer"), url, false);
-    </script>
-
-    <p><a href="/">Back to Home</a></p>
-  </body>
-</html>
-`
+// Lab 7, 8, 9: Use these templates to render the web pages
+
+package web
+
+const indexHTML = `
+<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="UTF-8" />
+    <title>TritonTube</title>
+    <style>
+      .grid { display: flex; flex-wrap: wrap; gap: 16px; list-style: none; padding: 0; }
+      .grid li { width: 240px; }
+      .grid img { width: 240px; height: 135px; object-fit: cover; background: #222; display: block; }
+    </style>
+  </head>
+  <body>
+    <h1>Welcome to TritonTube</h1>
+    {{if .User}}
+    <form action="/logout" method="post">
+      Logged in as {{.User}} <input type="submit" value="Logout" />
+    </form>
+    <h2>Upload a Video</h2>
+    <form action="/upload" method="post" enctype="multipart/form-data">
+      <input type="file" name="file" accept="video/*,.mp4,.mov,.mkv,.webm,.avi" required />
+      <select name="visibility">
+        <option value="public">Public</option>
+        <option value="unlisted">Unlisted</option>
+        <option value="private">Private</option>
+      </select>
+      <input type="submit" value="Upload" />
+    </form>
+    {{if .Jobs}}
+    <h2>Recent Uploads</h2>
+    <ul>
+      {{range .Jobs}}
+      <li>
+        <form action="/jobs/{{.Id}}/cancel" method="post">
+          <a href="/jobs/{{.Id}}">{{.VideoId}}</a> (started {{.StartedAt}}): {{.State}}{{if eq .State "running"}} {{.Progress}} <input type="submit" value="Cancel" />{{end}}
+        </form>
+      </li>
+      {{end}}
+    </ul>
+    {{end}}
+    {{else if .AccountsEnabled}}
+    <p><a href="/login">Login</a> or <a href="/register">Register</a> to upload videos.</p>
+    {{end}}
+    <h2>Watchlist</h2>
+    <ul class="grid">
+      {{range .Videos}}
+      <li>
+        <a href="/videos/{{.EscapedId}}"><img src="{{.PosterURL}}" alt="" loading="lazy" onerror="this.removeAttribute('src')" /></a>
+        <a href="/videos/{{.EscapedId}}">{{.Id}} ({{.UploadTime}})</a>{{if .Uploader}} by {{.Uploader}}{{end}}{{if ne .Visibility "public"}} [{{.Visibility}}]{{end}}
+      </li>
+      {{else}}
+      <li>No videos uploaded yet.</li>
+      {{end}}
+    </ul>
+  </body>
+</html>
+`
+
+const videoHTML = `
+<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="UTF-8" />
+    <title>{{.Id}} - TritonTube</title>
+    <script src="https://cdn.dashjs.org/latest/dash.all.min.js"></script>
+    <style>
+      .player { position: relative; width: 640px; }
+      #seekPreview { display: none; position: absolute; bottom: 48px; border: 1px solid #fff; pointer-events: none; }
+    </style>
+  </head>
+  <body>
+    <h1>{{.Id}}</h1>
+	  <p>Uploaded at: {{.UploadedAt}}{{if .Uploader}} by {{.Uploader}}{{end}}{{if ne .Visibility "public"}} ({{.Visibility}}){{end}}</p>
+    {{if .StorageUsed}}<p>Storage used: {{.StorageUsed}}</p>{{end}}
+    {{if .AudioLanguages}}<p>Audio languages: {{range $i, $lang := .AudioLanguages}}{{if $i}}, {{end}}{{$lang}}{{end}}</p>{{end}}
+
+    <div class="player">
+      <video id="dashPlayer" controls poster="{{.PosterURL}}" style="width: 640px; height: 360px">
+        <track id="thumbnails" kind="metadata" src="{{.ThumbnailsURL}}" />
+        {{range .Subtitles}}
+        <track kind="subtitles" srclang="{{.Language}}" label="{{.Label}}" src="{{.URL}}" />
+        {{end}}
+      </video>
+      <div id="seekPreview"></div>
+    </div>
+    <script>
+      var url = {{.ManifestURL}};
+      var video = document.querySelector("#dashPlayer");
+      var player = dashjs.MediaPlayer().create();
+      player.initialize(video, url, false);
+
+      // seek-bar previews: thumbnail cues read "sprite-N.jpg#xywh=x,y,w,h"
+      var thumbnails = document.querySelector("#thumbnails");
+      var preview = document.querySelector("#seekPreview");
+      thumbnails.track.mode = "hidden";
+      video.addEventListener("mousemove", function (e) {
+        var rect = video.getBoundingClientRect();
+        var cues = thumbnails.track.cues;
+        preview.style.display = "none";
+        // only over the control bar at the bottom of the player
+        if (!cues || !video.duration || e.clientY < rect.bottom - 40) {
+          return;
+        }
+        var time = (e.clientX - rect.left) / rect.width * video.duration;
+        for (var i = 0; i < cues.length; i++) {
+          if (time < cues[i].startTime || time >= cues[i].endTime) {
+            continue;
+          }
+          var parts = cues[i].text.split("#xywh=");
+          var xywh = parts[1].split(",").map(Number);
+          preview.style.backgroundImage = "url(" + new URL(parts[0], thumbnails.src) + ")";
+          preview.style.backgroundPosition = -xywh[0] + "px " + -xywh[1] + "px";
+          preview.style.width = xywh[2] + "px";
+          preview.style.height = xywh[3] + "px";
+          preview.style.left = Math.min(Math.max(e.clientX - rect.left - xywh[2] / 2, 0), rect.width - xywh[2]) + "px";
+          preview.style.display = "block";
+          return;
+        }
+      });
+      video.addEventListener("mouseleave", function () {
+        preview.style.display = "none";
+      });
+    </script>
+
+    <p id="audioChoice" style="display: none">
+      Audio: <select id="audio"></select>
+    </p>
+    <script>
+      // offer the audio AdaptationSets once dash.js has read the manifest
+      var audioLanguages = {{.AudioLanguages}} || [];
+      player.on(dashjs.MediaPlayer.events.STREAM_INITIALIZED, function () {
+        var tracks = player.getTracksFor("audio");
+        if (tracks.length < 2) {
+          return;
+        }
+        var select = document.querySelector("#audio");
+        var current = player.getCurrentTrackFor("audio");
+        select.innerHTML = "";
+        tracks.forEach(function (track, i) {
+          var option = document.createElement("option");
+          option.value = i;
+          option.textContent = track.lang || audioLanguages[i] || "Track " + (i + 1);
+          option.selected = track === current;
+          select.appendChild(option);
+        });
+        select.onchange = function () {
+          player.setCurrentTrack(tracks[select.value]);
+        };
+        document.querySelector("#audioChoice").style.display = "";
+      });
+    </script>
+
+    {{if .Subtitles}}
+    <p>
+      Captions:
+      <select id="captions">
+        <option value="">Off</option>
+        {{range .Subtitles}}<option value="{{.Language}}">{{.Label}}</option>{{end}}
+      </select>
+    </p>
+    <script>
+      // the caption menu switches between the subtitle <track>s; dash.js leaves them alone
+      document.querySelector("#captions").addEventListener("change", function (e) {
+        for (var i = 0; i < video.textTracks.length; i++) {
+          var track = video.textTracks[i];
+          if (track.kind === "subtitles") {
+            track.mode = track.language === e.target.value ? "showing" : "disabled";
+          }
+        }
+      });
+    </script>
+    {{end}}
+
+    {{if .CanEdit}}
+    <h2>Subtitles</h2>
+    <ul>
+      {{range .Subtitles}}
+      <li>
+        <form action="/videos/{{$.EscapedId}}/subtitles/{{.Language}}/delete" method="post">
+          {{.Label}} ({{.Language}}) <input type="submit" value="Remove" />
+        </form>
+      </li>
+      {{else}}
+      <li>No subtitles yet.</li>
+      {{end}}
+    </ul>
+    <form action="/videos/{{.EscapedId}}/subtitles" method="post" enctype="multipart/form-data">
+      <input type="file" name="file" accept=".srt,.vtt" required />
+      <input type="text" name="language" placeholder="Language, e.g. en" required />
+      <input type="text" name="label" placeholder="Label, e.g. English" />
+      <input type="submit" value="Add subtitles" />
+    </form>
+    {{end}}
+
+    <p><a href="/">Back to Home</a></p>
+  </body>
+</html>
+`
+
+const authHTML = `
+<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="UTF-8" />
+    <title>{{.Title}} - TritonTube</title>
+  </head>
+  <body>
+    <h1>{{.Title}}</h1>
+    {{if .Error}}<p style="color: red">{{.Error}}</p>{{end}}
+    <form action="{{.Action}}" method="post">
+      <p><label>Username <input type="text" name="username" required autofocus /></label></p>
+      <p><label>Password <input type="password" name="password" required /></label></p>
+      <input type="submit" value="{{.Title}}" />
+    </form>
+
+    <p><a href="/">Back to Home</a></p>
+  </body>
+</html>
+`
+
+const jobHTML = `
+<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="UTF-8" />
+    <title>Upload of {{.VideoId}} - TritonTube</title>
+    {{if .Running}}<meta http-equiv="refresh" content="2" />{{end}}
+  </head>
+  <body>
+    <h1>Upload of {{.VideoId}}</h1>
+    <p>Started at: {{.StartedAt}}</p>
+    {{if .Running}}
+    <p>Transcoding: <progress max="100" value="{{.Progress}}"></progress> {{printf "%.0f" .Progress}}%</p>
+    <form action="/jobs/{{.Id}}/cancel" method="post">
+      <input type="submit" value="Cancel" />
+    </form>
+    {{else if eq .State "succeeded"}}
+    <p>Done: <a href="/videos/{{.EscapedVideoId}}">watch {{.VideoId}}</a></p>
+    {{else}}
+    <p style="color: red">{{if eq .State "cancelled"}}Cancelled{{else}}Failed{{end}}: {{.Error}}</p>
+    {{end}}
+    {{if .Log}}
+    <h2>ffmpeg output</h2>
+    <pre style="max-height: 480px; overflow: auto; background: #f4f4f4; padding: 8px">{{.Log}}</pre>
+    {{end}}
+    <p><a href="/">Back to Home</a></p>
+  </body>
+</html>
+`
+
+const duplicateHTML = `
+<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="UTF-8" />
+    <title>Already uploaded - TritonTube</title>
+  </head>
+  <body>
+    <h1>Already uploaded</h1>
+    <p>This file has already been uploaded as <a href="/videos/{{.EscapedId}}">{{.Id}}</a>.</p>
+    <p><a href="/">Back to Home</a></p>
+  </body>
+</html>
+`
@@ -0,0 +1,127 @@
+// Poster frames, thumbnail sprite sheets and the WebVTT track that indexes them
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	posterFilename     = "poster.jpg"
+	thumbnailsFilename = "thumbnails.vtt"
+
+	// one thumbnail is taken every thumbnailInterval
+	thumbnailInterval = 10 * time.Second
+	thumbnailWidth    = 160
+	thumbnailHeight   = 90
+	// each sprite sheet holds up to spriteColumns x spriteRows thumbnails
+	spriteColumns = 10
+	spriteRows    = 10
+)
+
+// spriteFilename names the n-th sprite sheet of a video.
+func spriteFilename(n int) string {
+	return fmt.Sprintf("sprite-%d.jpg", n)
+}
+
+// generatePoster writes a representative frame of inputPath to outputDir, as the poster of version.
+func generatePoster(ctx context.Context, job *transcodeJob, inputPath string, outputDir string, version string) error {
+	return job.command(ctx, "ffmpeg",
+		"-i", inputPath,
+		// thumbnail picks the most representative of the first frames, skipping fades from black
+		"-vf", "thumbnail,scale=640:-2",
+		"-frames:v", "1",
+		filepath.Join(outputDir, versionedFilename(version, posterFilename)),
+	).Run()
+}
+
+// generateThumbnails writes sprite sheets of frames taken every
+// thumbnailInterval, plus a WebVTT track mapping time ranges to sprite regions,
+// to outputDir, named after version. workDir holds the individual frames.
+func generateThumbnails(ctx context.Context, job *transcodeJob, inputPath string, workDir string, outputDir string, version string) error {
+	framesDir := filepath.Join(workDir, "frames")
+	if err := os.MkdirAll(framesDir, 0755); err != nil {
+		return err
+	}
+	cmd := job.command(ctx, "ffmpeg",
+		"-i", inputPath,
+		"-vf", fmt.Sprintf("fps=1/%d,scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2",
+			int(thumbnailInterval.Seconds()), thumbnailWidth, thumbnailHeight, thumbnailWidth, thumbnailHeight),
+		filepath.Join(framesDir, "%05d.jpg"),
+	)
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	// ffmpeg numbers from 1 with zero padding, so sorted names are in time order
+	frames, err := filepath.Glob(filepath.Join(framesDir, "*.jpg"))
+	if err != nil {
+		return err
+	}
+	if len(frames) == 0 {
+		return fmt.Errorf("ffmpeg produced no thumbnails")
+	}
+
+	var vtt strings.Builder
+	vtt.WriteString("WEBVTT\n")
+	perSprite := spriteColumns * spriteRows
+	for first := 0; first < len(frames); first += perSprite {
+		sheet := frames[first:min(first+perSprite, len(frames))]
+		rows := (len(sheet) + spriteColumns - 1) / spriteColumns
+		sprite := image.NewRGBA(image.Rect(0, 0, spriteColumns*thumbnailWidth, rows*thumbnailHeight))
+		name := versionedFilename(version, spriteFilename(first/perSprite))
+
+		for i, frame := range sheet {
+			img, err := readJPEG(frame)
+			if err != nil {
+				return err
+			}
+			x, y := (i%spriteColumns)*thumbnailWidth, (i/spriteColumns)*thumbnailHeight
+			draw.Draw(sprite, image.Rect(x, y, x+thumbnailWidth, y+thumbnailHeight), img, img.Bounds().Min, draw.Src)
+
+			start := time.Duration(first+i) * thumbnailInterval
+			fmt.Fprintf(&vtt, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+				vttTimestamp(start), vttTimestamp(start+thumbnailInterval), name, x, y, thumbnailWidth, thumbnailHeight)
+		}
+
+		if err := writeJPEG(filepath.Join(outputDir, name), sprite); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filepath.Join(outputDir, versionedFilename(version, thumbnailsFilename)), []byte(vtt.String()), 0644)
+}
+
+func readJPEG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return jpeg.Decode(file)
+}
+
+func writeJPEG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(file, img, &jpeg.Options{Quality: 75}); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// vttTimestamp formats d as hh:mm:ss.mmm.
+func vttTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,146 @@
+// Transcoding uploads to DASH with ffmpeg
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodingProfile is how sources are transcoded to DASH. The zero value
+// leaves every choice to ffmpeg and keeps the source resolution.
+type EncodingProfile struct {
+	// VideoCodec and AudioCodec are ffmpeg encoder names such as libx264 and aac.
+	VideoCodec string
+	AudioCodec string
+	// Ladder lists the heights of the video renditions, e.g. 1080, 720 and 480.
+	// Renditions taller than the source are left out; when that leaves none,
+	// or the ladder is empty, the source height is used.
+	Ladder []int
+	// SegmentDuration is the length of a DASH segment.
+	SegmentDuration time.Duration
+}
+
+// renditions returns the heights to encode a source of the given height at.
+func (p EncodingProfile) renditions(sourceHeight int) []int {
+	var heights []int
+	for _, height := range p.Ladder {
+		if height <= sourceHeight && !slices.Contains(heights, height) {
+			heights = append(heights, height)
+		}
+	}
+	if len(heights) == 0 {
+		return []int{0} // no scaling
+	}
+	return heights
+}
+
+// transcodeToDASH writes the manifest and segments of version to outputDir.
+// The main video stream is encoded at each rendition of the profile, in one
+// AdaptationSet, and every audio stream is kept; each audio stream gets its
+// own AdaptationSet with a lang attribute, so players can offer a choice
+// between them. The job's progress follows ffmpeg's.
+func transcodeToDASH(ctx context.Context, job *transcodeJob, inputPath string, outputDir string, probe *mediaProbe, profile EncodingProfile, version string) error {
+	// progress goes to stdout as key=value lines; -nostats keeps the same
+	// numbers from filling the log
+	args := []string{"-nostats", "-progress", "pipe:1", "-i", inputPath}
+	var adaptationSets []string
+	output := 0 // index of the next stream in the output
+	if video := probe.videoStream(); video != nil {
+		var streams []string
+		for i, height := range profile.renditions(video.Height) {
+			args = append(args, "-map", fmt.Sprintf("0:%d", video.Index))
+			if height > 0 {
+				args = append(args, fmt.Sprintf("-filter:v:%d", i), fmt.Sprintf("scale=-2:%d", height))
+			}
+			streams = append(streams, strconv.Itoa(output))
+			output++
+		}
+		adaptationSets = append(adaptationSets, fmt.Sprintf("id=0,streams=%s", strings.Join(streams, ",")))
+	}
+	for i, audio := range probe.audioStreams() {
+		args = append(args,
+			"-map", fmt.Sprintf("0:%d", audio.Index),
+			fmt.Sprintf("-metadata:s:a:%d", i), "language="+audio.language(),
+		)
+		adaptationSets = append(adaptationSets, fmt.Sprintf("id=%d,streams=%d", len(adaptationSets), output))
+		output++
+	}
+	if output == 0 {
+		return &requestError{http.StatusBadRequest, "unsupported_media", "the file has no audio or video streams"}
+	}
+
+	if profile.VideoCodec != "" {
+		args = append(args, "-c:v", profile.VideoCodec)
+	}
+	if profile.AudioCodec != "" {
+		args = append(args, "-c:a", profile.AudioCodec)
+	}
+	if profile.SegmentDuration > 0 {
+		args = append(args, "-seg_duration", strconv.FormatFloat(profile.SegmentDuration.Seconds(), 'f', -1, 64))
+	}
+	args = append(args,
+		"-f", "dash",
+		"-adaptation_sets", strings.Join(adaptationSets, " "),
+		"-init_seg_name", versionedFilename(version, "init-$RepresentationID$.$ext$"),
+		"-media_seg_name", versionedFilename(version, "chunk-$RepresentationID$-$Number%05d$.$ext$"),
+		filepath.Join(outputDir, versionedFilename(version, manifestFilename)),
+	)
+	cmd := job.command(ctx, "ffmpeg", args...)
+	duration, _ := strconv.ParseFloat(probe.Format.Duration, 64)
+	cmd.Stdout = &progressWriter{job: job, duration: duration}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg: %v: %s", err, job.log.lastLines(3))
+	}
+	return nil
+}
+
+// progressWriter parses the output of ffmpeg -progress into the job's progress.
+type progressWriter struct {
+	job *transcodeJob
+	// duration of the input in seconds, or 0 when unknown
+	duration float64
+	partial  []byte
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.partial = append(p.partial, b...)
+	for {
+		line, rest, ok := bytes.Cut(p.partial, []byte("\n"))
+		if !ok {
+			break
+		}
+		p.partial = rest
+		key, value, _ := strings.Cut(strings.TrimSpace(string(line)), "=")
+		switch key {
+		case "out_time_us":
+			// N/A until the first frame is written
+			us, err := strconv.ParseInt(value, 10, 64)
+			if err == nil && p.duration > 0 {
+				// 100 only once ffmpeg says it is done
+				p.job.setProgress(min(float64(us)/1e6/p.duration*100, 99))
+			}
+		case "progress":
+			if value == "end" {
+				p.job.setProgress(100)
+			}
+		}
+	}
+	return len(b), nil
+}
+
+// audioLanguages lists the language of each audio stream in output order.
+func audioLanguages(probe *mediaProbe) []string {
+	var languages []string
+	for _, audio := range probe.audioStreams() {
+		languages = append(languages, audio.language())
+	}
+	return languages
+}
@@ -0,0 +1,239 @@
+// Storage accounting per file, video and uploader, and per-user storage quotas
+
+package web
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Stores whose file sizes are recorded, for UsageService.
+const (
+	contentStore   = "content"
+	originalsStore = "originals"
+)
+
+// accountingContentService records, in a UsageService, the size of every file
+// written through it. Sizes are the bytes written, before any deduplication
+// below, so identical uploads by two users count against both.
+type accountingContentService struct {
+	content VideoContentService
+	usage   UsageService
+	store   string
+}
+
+// accountingLister is an accountingContentService over a content service
+// that can list its files, which it keeps being.
+type accountingLister struct {
+	*accountingContentService
+	ContentLister
+}
+
+// withAccounting wraps content so the sizes of its files are recorded in usage under store.
+func withAccounting(content VideoContentService, usage UsageService, store string) VideoContentService {
+	accounting := &accountingContentService{content: content, usage: usage, store: store}
+	if lister, ok := content.(ContentLister); ok {
+		return &accountingLister{accounting, lister}
+	}
+	return accounting
+}
+
+// WRITE
+func (a *accountingContentService) Write(videoId string, filename string, data []byte) error {
+	if err := a.content.Write(videoId, filename, data); err != nil {
+		return err
+	}
+	return a.usage.SetFileSize(a.store, videoId, filename, int64(len(data)))
+}
+
+// READ
+func (a *accountingContentService) Read(videoId string, filename string) ([]byte, error) {
+	return a.content.Read(videoId, filename)
+}
+
+// DELETE
+func (a *accountingContentService) Delete(videoId string) error {
+	if err := a.content.Delete(videoId); err != nil {
+		return err
+	}
+	return a.usage.DeleteVideoSizes(a.store, videoId)
+}
+
+// DELETE FILE
+func (a *accountingContentService) DeleteFile(videoId string, filename string) error {
+	if err := a.content.DeleteFile(videoId, filename); err != nil {
+		return err
+	}
+	return a.usage.DeleteFileSize(a.store, videoId, filename)
+}
+
+var _ VideoContentService = (*accountingContentService)(nil)
+var _ ContentLister = (*accountingLister)(nil)
+
+// checkQuota rejects storing adding more bytes for uploader when that would
+// take them over the quota. With adding 0 it only checks that some of the quota
+// is left. Concurrent uploads by one user are each checked against what is
+// stored already, so together they can overshoot the quota a little.
+func (s *server) checkQuota(uploader string, adding int64) error {
+	if s.quota <= 0 || s.usage == nil {
+		return nil
+	}
+	used, err := s.usage.UploaderUsage(uploader)
+	if err != nil {
+		return err
+	}
+	if used+adding <= s.quota && (adding > 0 || used < s.quota) {
+		return nil
+	}
+
+	message := fmt.Sprintf("this upload needs %s, but only %s of your %s storage quota is left",
+		formatSize(adding), formatSize(max(s.quota-used, 0)), formatSize(s.quota))
+	if adding == 0 {
+		message = fmt.Sprintf("your %s storage quota is used up; delete videos to upload more", formatSize(s.quota))
+	}
+	return &limitError{
+		requestError: &requestError{http.StatusRequestEntityTooLarge, "quota_exceeded", message},
+		limit:        "storage quota",
+		maximum:      float64(s.quota),
+		actual:       float64(used + adding),
+		unit:         "bytes",
+	}
+}
+
+// ownVideoUsage returns the bytes stored for video when user uploaded it, as
+// it counts against their quota. It is nil for other users, and when the
+// video's sizes aren't tracked.
+func (s *server) ownVideoUsage(video *VideoMetadata, user string) *int64 {
+	if s.usage == nil || user == "" || user != video.Uploader {
+		return nil
+	}
+	used, tracked, err := s.usage.VideoUsage(video.Id)
+	if err != nil {
+		// usage is shown alongside the video; failing to get it shouldn't hide the video
+		log.Printf("Reading storage usage of %s: %v", video.Id, err)
+		return nil
+	}
+	if !tracked {
+		return nil
+	}
+	return &used
+}
+
+// ownVideosUsage returns the bytes stored for each of user's videos whose
+// sizes are tracked, for listing many videos at once. It is nil when usage
+// isn't tracked.
+func (s *server) ownVideosUsage(user string) map[string]int64 {
+	if s.usage == nil || user == "" {
+		return nil
+	}
+	usage, err := s.usage.UploaderVideoUsage(user)
+	if err != nil {
+		log.Printf("Reading storage usage of %s's videos: %v", user, err)
+		return nil
+	}
+	return usage
+}
+
+// backfillUsage records the sizes of files stored before sizes were recorded,
+// for the videos that have none recorded in a store. Until it is done, those
+// videos don't count against quotas and their usage isn't shown.
+func (s *server) backfillUsage() {
+	if s.usage == nil {
+		return
+	}
+	videos, err := s.metadataService.List()
+	if err != nil {
+		log.Printf("Backfilling storage usage: %v", err)
+		return
+	}
+	exists := make(map[string]bool, len(videos))
+	for _, video := range videos {
+		exists[video.Id] = true
+	}
+
+	stores := []struct {
+		name    string
+		content VideoContentService
+	}{{contentStore, s.contentService}, {originalsStore, s.originals}}
+	for _, store := range stores {
+		if store.content == nil {
+			continue
+		}
+		lister, ok := store.content.(ContentLister)
+		if !ok {
+			log.Printf("Can't backfill storage usage: the %s service can't list files", store.name)
+			continue
+		}
+		trackedIds, err := s.usage.ListTrackedVideos(store.name)
+		if err != nil {
+			log.Printf("Backfilling storage usage of %s: %v", store.name, err)
+			continue
+		}
+		tracked := make(map[string]bool, len(trackedIds))
+		for _, videoId := range trackedIds {
+			tracked[videoId] = true
+		}
+		files, err := lister.ListAll()
+		if err != nil {
+			log.Printf("Backfilling storage usage of %s: %v", store.name, err)
+			continue
+		}
+
+		backfilled := make(map[string]bool)
+		for _, file := range files {
+			// files of videos without metadata are orphans, or uploads that record their own sizes
+			if !exists[file.VideoId] || tracked[file.VideoId] {
+				continue
+			}
+			if err := s.usage.SetFileSize(store.name, file.VideoId, file.Filename, file.Size); err != nil {
+				log.Printf("Backfilling storage usage of %s/%s: %v", file.VideoId, file.Filename, err)
+				continue
+			}
+			backfilled[file.VideoId] = true
+		}
+		if len(backfilled) > 0 {
+			log.Printf("Recorded the %s storage usage of %d videos stored before it was tracked", store.name, len(backfilled))
+		}
+	}
+}
+
+// formatSize formats n bytes for people, e.g. "1.5 MiB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// apiUsage is the JSON form of the caller's storage usage.
+type apiUsage struct {
+	UsedBytes int64 `json:"used_bytes"`
+	// QuotaBytes is absent when uploads are not capped
+	QuotaBytes int64 `json:"quota_bytes,omitempty"`
+}
+
+// GET /api/usage
+func (s *server) handleAPIUsage(w http.ResponseWriter, r *http.Request) {
+	user := s.currentUser(r)
+	if user == "" {
+		writeAPIError(w, &requestError{http.StatusUnauthorized, "unauthenticated", "log in to see your storage usage"})
+		return
+	}
+	if s.usage == nil {
+		writeAPIError(w, &requestError{http.StatusNotImplemented, "not_implemented", "this server doesn't track storage usage"})
+		return
+	}
+	used, err := s.usage.UploaderUsage(user)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, apiUsage{UsedBytes: used, QuotaBytes: s.quota})
+}
@@ -0,0 +1,162 @@
+package web
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestUsage returns a SQLite metadata service, which also records usage,
+// holding video "a" uploaded by alice and video "b" uploaded by bob.
+func newTestUsage(t *testing.T) *SQLiteVideoMetadataService {
+	t.Helper()
+	metadata, err := NewSQLiteVideoMetadataService(filepath.Join(t.TempDir(), "metadata.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, video := range []VideoMetadata{
+		{Id: "a", Uploader: "alice", Visibility: VisibilityPublic, UploadedAt: time.Now()},
+		{Id: "b", Uploader: "bob", Visibility: VisibilityPublic, UploadedAt: time.Now()},
+	} {
+		if err := metadata.Create(video); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return metadata
+}
+
+func TestAccountingContentService(t *testing.T) {
+	metadata := newTestUsage(t)
+	content := withAccounting(NewFSVideoContentService(t.TempDir()), metadata, contentStore)
+	originals := withAccounting(NewFSVideoContentService(t.TempDir()), metadata, originalsStore)
+	if _, ok := content.(ContentLister); !ok {
+		t.Fatal("accounting hides that the content service can list files")
+	}
+
+	steps := []struct {
+		name string
+		do   func() error
+		// bytes recorded for alice and bob afterwards
+		alice, bob int64
+	}{
+		{"write", func() error { return content.Write("a", "seg1", make([]byte, 100)) }, 100, 0},
+		{"write another file", func() error { return content.Write("a", "seg2", make([]byte, 50)) }, 150, 0},
+		{"write to another store", func() error { return originals.Write("a", originalFilename, make([]byte, 1000)) }, 1150, 0},
+		{"overwrite", func() error { return content.Write("a", "seg1", make([]byte, 10)) }, 1060, 0},
+		{"other uploader", func() error { return content.Write("b", "seg1", make([]byte, 7)) }, 1060, 7},
+		{"delete file", func() error { return content.DeleteFile("a", "seg2") }, 1010, 7},
+		{"delete video from one store", func() error { return content.Delete("a") }, 1000, 7},
+		{"delete video from the other", func() error { return originals.Delete("a") }, 0, 7},
+	}
+	for _, step := range steps {
+		if err := step.do(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		for uploader, want := range map[string]int64{"alice": step.alice, "bob": step.bob} {
+			used, err := metadata.UploaderUsage(uploader)
+			if err != nil || used != want {
+				t.Errorf("after %s: %s uses %d bytes, %v, want %d", step.name, uploader, used, err, want)
+			}
+		}
+	}
+
+	if _, tracked, err := metadata.VideoUsage("a"); err != nil || tracked {
+		t.Errorf("video a is tracked after deleting it everywhere: %v", err)
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	metadata := newTestUsage(t)
+	// alice has 600 of her 1000 bytes stored
+	if err := metadata.SetFileSize(contentStore, "a", "seg1", 600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		quota    int64
+		uploader string
+		adding   int64
+		exceeded bool
+	}{
+		{"room left before transcoding", 1000, "alice", 0, false},
+		{"fits after transcoding", 1000, "alice", 400, false},
+		{"exceeded after transcoding", 1000, "alice", 401, true},
+		{"other uploader", 1000, "bob", 1000, false},
+		{"used up before transcoding", 600, "alice", 0, true},
+		{"no quota", 0, "alice", 1 << 40, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{usage: metadata, quota: test.quota}
+			err := s.checkQuota(test.uploader, test.adding)
+			var limitErr *limitError
+			if errors.As(err, &limitErr) != test.exceeded {
+				t.Fatalf("checkQuota(%q, %d) = %v, want exceeded: %v", test.uploader, test.adding, err, test.exceeded)
+			}
+			if limitErr == nil {
+				return
+			}
+			if limitErr.code != "quota_exceeded" || limitErr.maximum != float64(test.quota) {
+				t.Errorf("checkQuota(%q, %d) = %s with a maximum of %g", test.uploader, test.adding, limitErr.code, limitErr.maximum)
+			}
+		})
+	}
+
+	// without usage tracking, quotas can't be enforced
+	if err := (&server{quota: 1}).checkQuota("alice", 1<<40); err != nil {
+		t.Errorf("checkQuota without usage tracking = %v", err)
+	}
+}
+
+// Files stored before sizes were recorded are counted once, and orphans not at all.
+func TestBackfillUsage(t *testing.T) {
+	metadata := newTestUsage(t)
+	fs := NewFSVideoContentService(t.TempDir())
+	for _, file := range []struct {
+		videoId  string
+		filename string
+		size     int
+	}{{"a", "seg1", 100}, {"a", "seg2", 20}, {"b", "manifest.mpd", 5}, {"orphan", "seg1", 1000}} {
+		if err := fs.Write(file.videoId, file.filename, make([]byte, file.size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// b records its own sizes already, which the backfill must not add to
+	if err := metadata.SetFileSize(contentStore, "b", "manifest.mpd", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &server{metadataService: metadata, contentService: fs, usage: metadata}
+	s.backfillUsage()
+	s.backfillUsage()
+
+	for uploader, want := range map[string]int64{"alice": 120, "bob": 5} {
+		used, err := metadata.UploaderUsage(uploader)
+		if err != nil || used != want {
+			t.Errorf("%s uses %d bytes, %v, want %d", uploader, used, err, want)
+		}
+	}
+	if _, tracked, err := metadata.VideoUsage("orphan"); err != nil || tracked {
+		t.Errorf("orphaned files were recorded: %v", err)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 30, "5.0 GiB"},
+	}
+	for _, test := range tests {
+		if got := formatSize(test.n); got != test.want {
+			t.Errorf("formatSize(%d) = %q, want %q", test.n, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Versioned content: each transcode of a video is stored under its own file
+// names, and replaced versions are deleted once viewers are done with them
+
+package web
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const manifestFilename = "manifest.mpd"
+
+// versionCollectInterval is how often retired versions are checked for
+// deletion, unless the grace period is shorter.
+const versionCollectInterval = 10 * time.Minute
+
+// legacyFilenames match the files of videos transcoded before versions
+// existed, which have the version "". Subtitles are not part of a version.
+var legacyFilenames = []string{manifestFilename, posterFilename, thumbnailsFilename, "sprite-*.jpg", "init-stream*", "chunk-stream*"}
+
+// newVersion names the output of one transcode. Versions of a video sort in
+// the order they were made.
+func newVersion() string {
+	return strconv.FormatInt(time.Now().UnixMilli(), 36)
+}
+
+// versionedFilename is the stored name of a file of version. The manifest
+// refers to segments by relative names, so every file of a version keeps
+// working through the same signed URL prefix.
+func versionedFilename(version string, filename string) string {
+	if version == "" {
+		return filename
+	}
+	return version + "-" + filename
+}
+
+// inVersion reports whether the stored file filename belongs to version.
+func inVersion(filename string, version string) bool {
+	if version != "" {
+		return strings.HasPrefix(filename, version+"-")
+	}
+	return slices.ContainsFunc(legacyFilenames, func(pattern string) bool {
+		matched, _ := path.Match(pattern, filename)
+		return matched
+	})
+}
+
+// errVersionConflict is a new version that lost to a change made while it was transcoded.
+var errVersionConflict = &requestError{http.StatusConflict, "version_conflict", "the video was changed or deleted while it was being transcoded"}
+
+// switchVersion makes version, transcoded with audioLanguages, the current
+// version of video in one metadata update, and retires the version it
+// replaces. video is as it was read before transcoding; when its version has
+// changed since, or it has been deleted, the new version is retired instead.
+func (s *server) switchVersion(video *VideoMetadata, version string, audioLanguages []string) error {
+	switched, err := s.metadataService.SetVersion(video.Id, video.Version, version, audioLanguages)
+	if err != nil {
+		return err
+	}
+	retired := video.Version
+	if !switched {
+		retired = version
+	}
+	err = s.versions.RetireVersion(RetiredVersion{VideoId: video.Id, Version: retired, RetiredAt: time.Now()})
+	if err != nil {
+		// the switch is done either way; the retired files are only wasted space
+		log.Printf("Retiring version %q of %s: %v", retired, video.Id, err)
+	}
+	if !switched {
+		return errVersionConflict
+	}
+	return nil
+}
+
+// collectVersionsEvery deletes retired versions past their grace period, checking
+// every interval until the process exits.
+func (s *server) collectVersionsEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := s.collectVersions(); err != nil {
+			log.Printf("Collecting old versions: %v", err)
+		}
+	}
+}
+
+// collectVersions deletes the files of versions retired more than the grace
+// period ago. Signed content URLs stay valid for a while, and a viewer holding
+// an old manifest keeps fetching its segments, so the grace period should be
+// at least the content URL lifetime. A version that fails to delete is tried
+// again next time.
+func (s *server) collectVersions() error {
+	retired, err := s.versions.ListRetiredVersions(time.Now().Add(-s.versionGrace))
+	if err != nil {
+		return err
+	}
+	for _, version := range retired {
+		if err := s.deleteVersion(version); err != nil {
+			log.Printf("Deleting version %q of %s: %v", version.Version, version.VideoId, err)
+			continue
+		}
+		if err := s.versions.ForgetVersion(version.VideoId, version.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteVersion deletes the files of a retired version.
+func (s *server) deleteVersion(retired RetiredVersion) error {
+	video, err := s.metadataService.Read(retired.VideoId)
+	if err != nil {
+		return err
+	}
+	if video == nil || video.Version == retired.Version {
+		// deleted with its video, or current again
+		return nil
+	}
+	lister, ok := s.contentService.(ContentLister)
+	if !ok {
+		return fmt.Errorf("the content service can't list files")
+	}
+	files, err := lister.ListFiles(retired.VideoId)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if !inVersion(file.Filename, retired.Version) {
+			continue
+		}
+		if err := s.contentService.DeleteFile(retired.VideoId, file.Filename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memoryVersionStore keeps retired versions until the server restarts, for
+// metadata stores without a VersionService. Versions retired before a restart
+// are never deleted.
+type memoryVersionStore struct {
+	mu       sync.Mutex
+	versions []RetiredVersion
+}
+
+// RETIRE VERSION
+func (m *memoryVersionStore) RetireVersion(version RetiredVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.versions = append(m.versions, version)
+	return nil
+}
+
+// LIST RETIRED VERSIONS
+func (m *memoryVersionStore) ListRetiredVersions(before time.Time) ([]RetiredVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var versions []RetiredVersion
+	for _, version := range m.versions {
+		if version.RetiredAt.Before(before) {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+// FORGET VERSION
+func (m *memoryVersionStore) ForgetVersion(videoId string, version string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.versions = slices.DeleteFunc(m.versions, func(v RetiredVersion) bool {
+		return v.VideoId == videoId && v.Version == version
+	})
+	return nil
+}
+
+var _ VersionService = (*memoryVersionStore)(nil)
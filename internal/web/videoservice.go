@@ -0,0 +1,187 @@
+// gRPC video API for trusted internal services, alongside the HTTP handlers
+
+package web
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// VideoService serves proto.VideoService from the same metadata and content
+// services, and the same upload pipeline, as the web server it wraps. It does
+// no per-user checks: visibility, ownership and the uploader are left to its
+// callers, which must be authenticated with rpcauth.
+type VideoService struct {
+	proto.UnimplementedVideoServiceServer
+	s *server
+}
+
+// Constructor
+func NewVideoService(s *server) *VideoService {
+	return &VideoService{s: s}
+}
+
+// grpcError turns a request error into the matching gRPC status.
+func grpcError(err error) error {
+	var reqErr *requestError
+	if !errors.As(err, &reqErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	switch reqErr {
+	case errJobCancelled:
+		return status.Error(codes.Canceled, reqErr.message)
+	case errJobTimedOut:
+		return status.Error(codes.DeadlineExceeded, reqErr.message)
+	}
+	code := map[int]codes.Code{
+		http.StatusBadRequest:            codes.InvalidArgument,
+		http.StatusUnauthorized:          codes.Unauthenticated,
+		http.StatusForbidden:             codes.PermissionDenied,
+		http.StatusNotFound:              codes.NotFound,
+		http.StatusConflict:              codes.AlreadyExists,
+		http.StatusRequestEntityTooLarge: codes.ResourceExhausted,
+		http.StatusUnsupportedMediaType:  codes.InvalidArgument,
+		http.StatusNotImplemented:        codes.Unimplemented,
+	}[reqErr.status]
+	if code == codes.OK {
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, reqErr.message)
+}
+
+// protoVideo converts video for the gRPC services.
+func (s *server) protoVideo(video *VideoMetadata) *proto.Video {
+	return &proto.Video{
+		Id:             video.Id,
+		UploadedAt:     timestamppb.New(video.UploadedAt),
+		Uploader:       video.Uploader,
+		Visibility:     string(video.Visibility),
+		ManifestUrl:    s.signer.contentURL(video.Id, versionedFilename(video.Version, manifestFilename), time.Now()),
+		AudioLanguages: video.AudioLanguages,
+	}
+}
+
+// LIST
+//
+// Page tokens are offsets into the newest-first list.
+func (v *VideoService) ListVideos(ctx context.Context, req *proto.ListVideosRequest) (*proto.ListVideosResponse, error) {
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+	offset := 0
+	if req.PageToken != "" {
+		var err error
+		offset, err = strconv.Atoi(req.PageToken)
+		if err != nil || offset < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	videos, err := v.s.metadataService.List()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	sortNewestFirst(videos)
+
+	resp := &proto.ListVideosResponse{}
+	if offset < len(videos) {
+		page := videos[offset:min(offset+pageSize, len(videos))]
+		for i := range page {
+			resp.Videos = append(resp.Videos, v.s.protoVideo(&page[i]))
+		}
+		if next := offset + len(page); next < len(videos) {
+			resp.NextPageToken = strconv.Itoa(next)
+		}
+	}
+	return resp, nil
+}
+
+// READ
+func (v *VideoService) GetVideo(ctx context.Context, req *proto.GetVideoRequest) (*proto.Video, error) {
+	video, err := v.s.metadataService.Read(req.Id)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if video == nil {
+		return nil, status.Errorf(codes.NotFound, "video %q not found", req.Id)
+	}
+	return v.s.protoVideo(video), nil
+}
+
+// uploadReader reads the chunks of an UploadVideo stream as one file.
+type uploadReader struct {
+	stream grpc.ClientStreamingServer[proto.UploadVideoRequest, proto.Video]
+	buf    []byte
+}
+
+func (u *uploadReader) Read(p []byte) (int, error) {
+	for len(u.buf) == 0 {
+		req, err := u.stream.Recv()
+		if err != nil {
+			return 0, err // io.EOF once the client closes its side
+		}
+		if req.GetMetadata() != nil {
+			return 0, &requestError{http.StatusBadRequest, "invalid_upload", "metadata may only be sent in the first message"}
+		}
+		u.buf = req.GetChunk()
+	}
+	n := copy(p, u.buf)
+	u.buf = u.buf[n:]
+	return n, nil
+}
+
+// UPLOAD
+func (v *VideoService) UploadVideo(stream grpc.ClientStreamingServer[proto.UploadVideoRequest, proto.Video]) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "empty upload")
+	} else if err != nil {
+		return err
+	}
+	metadata := first.GetMetadata()
+	if metadata == nil {
+		return status.Error(codes.InvalidArgument, "the first message must carry the video metadata")
+	}
+	visibility, ok := ParseVisibility(metadata.Visibility)
+	if !ok {
+		return status.Error(codes.InvalidArgument, "visibility must be public, unlisted or private")
+	}
+
+	video, err := v.s.ingestVideo(stream.Context(), metadata.Id, metadata.Uploader, visibility, &uploadReader{stream: stream})
+	if err != nil {
+		if code := status.Code(err); code != codes.Unknown {
+			return err // the stream itself failed
+		}
+		return grpcError(err)
+	}
+	return stream.SendAndClose(v.s.protoVideo(video))
+}
+
+// DELETE
+func (v *VideoService) DeleteVideo(ctx context.Context, req *proto.DeleteVideoRequest) (*proto.DeleteVideoResponse, error) {
+	video, err := v.s.metadataService.Read(req.Id)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if video == nil {
+		return nil, status.Errorf(codes.NotFound, "video %q not found", req.Id)
+	}
+	if err := v.s.deleteVideo(req.Id); err != nil {
+		return nil, grpcError(err)
+	}
+	return &proto.DeleteVideoResponse{}, nil
+}
+
+var _ proto.VideoServiceServer = (*VideoService)(nil)